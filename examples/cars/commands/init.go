@@ -8,7 +8,6 @@ import (
 	"net/url"
 	"os"
 	"path"
-	"time"
 
 	"github.com/IBM-Blockchain/bcdb-sdk/pkg/bcdb"
 	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
@@ -57,7 +56,11 @@ func createUserSession(demoDir string, bcdb bcdb.BCDB, user string) (bcdb.DBSess
 			CertPath:       path.Join(demoDir, "crypto", user, user+".pem"),
 			PrivateKeyPath: path.Join(demoDir, "crypto", user, user+".key"),
 		},
-		TxTimeout: time.Second * 5,
+		TxTimeout: config.DefaultTxTimeout,
+		// ledger checkpoint anchors verification of transaction evidence
+		LedgerVerification: &config.LedgerVerificationConfig{
+			CheckpointPath: path.Join(demoDir, user+"-ledger-checkpoint.json"),
+		},
 	})
 	return session, err
 }
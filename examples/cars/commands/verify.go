@@ -3,8 +3,16 @@
 package commands
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
 
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/bcdb"
 	"github.com/pkg/errors"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/logger"
 )
@@ -37,19 +45,120 @@ func VerifyEvidence(demoDir, userID, txID string, lg *logger.SugarLogger) (out s
 		return "", errors.Wrap(err, "error creating data transaction")
 	}
 
-	txProof, err := ledger.GetTransactionProof(txRcpt.Header.BaseHeader.Number, int(txRcpt.TxIndex))
+	report, err := ledger.VerifyTx(txEnv, txRcpt)
 	if err != nil {
-		return "", errors.Wrap(err, "error getting transaction proof")
+		return "", errors.Wrap(err, "error verifying transaction evidence")
 	}
 
-	lg.Infof("Transaction proof from BCDB server: %+v", txProof)
+	lg.Infof("Transaction verification report: %+v", report)
+	ok := report.Verified()
+	lg.Infof("Verified evidence for txID: %s, result: %t", txID, ok)
+	if !ok {
+		return fmt.Sprintf("VerifyEvidence: txID: %s, result: %t, failures: %s", txID, ok, strings.Join(report.Failures, "; ")), nil
+	}
+
+	return fmt.Sprintf("VerifyEvidence: txID: %s, result: %t", txID, ok), nil
+}
+
+// ExportProof packs Tx evidence, along with Tx proof, ledger path and nodes certificates, into a proof bundle file
+func ExportProof(demoDir, userID, txID string, lg *logger.SugarLogger) (out string, err error) {
+	lg.Debugf("user-ID: %s, txID: %s", userID, txID)
 
-	ok, err := txProof.Verify(txRcpt, txEnv)
+	txEnv, txRcpt, err := loadTxEvidence(demoDir, txID, lg)
 	if err != nil {
-		return "", errors.Wrap(err, "error verifying transaction evidence against the proof")
+		return "", errors.Wrap(err, "error loading transaction evidence")
 	}
 
-	lg.Infof("Verified evidence for txID: %s, result: %t", txID, ok)
+	serverUrl, err := loadServerUrl(demoDir)
+	if err != nil {
+		return "", errors.Wrap(err, "error loading server URL")
+	}
 
-	return fmt.Sprintf("VerifyEvidence: txID: %s, result: %t", txID, ok), nil
+	db, err := createDBInstance(demoDir, serverUrl)
+	if err != nil {
+		return "", errors.Wrap(err, "error creating database instance")
+	}
+
+	session, err := createUserSession(demoDir, db, userID)
+	if err != nil {
+		return "", errors.Wrap(err, "error creating database session")
+	}
+
+	ledger, err := session.Ledger()
+	if err != nil {
+		return "", errors.Wrap(err, "error creating ledger")
+	}
+
+	bundle, err := bcdb.ExportProofBundle(context.Background(), ledger, txEnv, txRcpt)
+	if err != nil {
+		return "", errors.Wrap(err, "error exporting proof bundle")
+	}
+
+	bundleBytes, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "error marshaling proof bundle")
+	}
+
+	bundleFile := path.Join(demoDir, "txs", txID+".proof.json")
+	if err = ioutil.WriteFile(bundleFile, bundleBytes, 0644); err != nil {
+		return "", errors.Wrap(err, "error saving proof bundle")
+	}
+
+	lg.Infof("Saved proof bundle, file: %s", bundleFile)
+
+	// The auditor should obtain the hash of the trusted block out of band, the demo prints the genesis block hash
+	genesis, err := ledger.GetBlockHeader(1)
+	if err != nil {
+		return "", errors.Wrap(err, "error fetching genesis block header")
+	}
+	genesisHash, err := bcdb.ComputeBlockHeaderHash(genesis)
+	if err != nil {
+		return "", errors.Wrap(err, "error computing genesis block hash")
+	}
+
+	return fmt.Sprintf("ExportProof: txID: %s, file: %s, genesis block hash: %s", txID, bundleFile, hex.EncodeToString(genesisHash)), nil
+}
+
+// VerifyProof verifies the proof bundle file offline, without access to the server. Node certificates in the
+// bundle are trusted if issued by the demo CA, the ledger path is verified from the block with hex encoded
+// trustedBlockHash. If trustedBlockHash is empty, the verification is unanchored and can't succeed
+func VerifyProof(demoDir, bundleFile, trustedBlockHash string, lg *logger.SugarLogger) (out string, err error) {
+	lg.Debugf("proof bundle file: %s, trusted block hash: %s", bundleFile, trustedBlockHash)
+
+	trustedHash, err := hex.DecodeString(trustedBlockHash)
+	if err != nil {
+		return "", errors.Wrap(err, "error decoding trusted block hash")
+	}
+
+	caBytes, err := ioutil.ReadFile(path.Join(demoDir, "crypto", "CA", "CA.pem"))
+	if err != nil {
+		return "", errors.Wrap(err, "error loading CA certificate")
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caBytes) {
+		return "", errors.New("error parsing CA certificate")
+	}
+
+	bundleBytes, err := ioutil.ReadFile(bundleFile)
+	if err != nil {
+		return "", errors.Wrap(err, "error loading proof bundle")
+	}
+
+	bundle := &bcdb.ProofBundle{}
+	if err = json.Unmarshal(bundleBytes, bundle); err != nil {
+		return "", errors.Wrap(err, "error unmarshaling proof bundle")
+	}
+
+	report, err := bcdb.VerifyProofBundle(bundle, &bcdb.ProofBundleTrust{RootCAs: roots, TrustedBlockHash: trustedHash})
+	if err != nil {
+		return "", errors.Wrap(err, "error verifying proof bundle")
+	}
+
+	lg.Infof("Proof bundle verification report: %+v", report)
+	ok := report.Verified()
+	if !ok {
+		return fmt.Sprintf("VerifyProof: txID: %s, result: %t, failures: %s", report.TxID, ok, strings.Join(report.Failures, "; ")), nil
+	}
+
+	return fmt.Sprintf("VerifyProof: txID: %s, result: %t", report.TxID, ok), nil
 }
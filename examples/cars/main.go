@@ -86,6 +86,14 @@ func executeForArgs(args []string, lg *logger.SugarLogger) (output string, exit
 	verUserID := verifyTx.Flag("user", "user ID").Short('u').Required().String()
 	verTxID := verifyTx.Flag("txid", "user ID").Short('t').Required().String()
 
+	exportProof := app.Command("export-proof", "Export Tx evidence, along with a BCDB Proof, into a proof bundle file")
+	expUserID := exportProof.Flag("user", "user ID").Short('u').Required().String()
+	expTxID := exportProof.Flag("txid", "transaction ID").Short('t').Required().String()
+
+	verifyProof := app.Command("verify-proof", "Verify a proof bundle file offline")
+	vpFile := verifyProof.Flag("file", "proof bundle file").Short('f').Required().String()
+	vpTrustedBlock := verifyProof.Flag("trusted-block", "hex encoded hash of the trusted block header, e.g. of the genesis block").Short('b').String()
+
 	command := kingpin.MustParse(app.Parse(args))
 
 	//
@@ -176,6 +184,24 @@ func executeForArgs(args []string, lg *logger.SugarLogger) (output string, exit
 			return errorOutput(err), 1, nil
 		}
 
+		return fmt.Sprintf("%s\n", out), 0, nil
+
+	case exportProof.FullCommand():
+		out, err := commands.ExportProof(*demoDir, *expUserID, *expTxID, lg)
+		if err != nil {
+			fmt.Println(command)
+			return errorOutput(err), 1, nil
+		}
+
+		return fmt.Sprintf("%s\n", out), 0, nil
+
+	case verifyProof.FullCommand():
+		out, err := commands.VerifyProof(*demoDir, *vpFile, *vpTrustedBlock, lg)
+		if err != nil {
+			fmt.Println(command)
+			return errorOutput(err), 1, nil
+		}
+
 		return fmt.Sprintf("%s\n", out), 0, nil
 	}
 
@@ -0,0 +1,243 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pkcs8 encrypts and decrypts PKCS#8 private keys with PBES2 scheme, defined by RFC 8018,
+// using PBKDF2 key derivation and AES-CBC encryption, the scheme used by `openssl pkcs8 -topk8 -v2`
+package pkcs8
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"hash"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultIterations the number of PBKDF2 iterations used to encrypt private key
+const DefaultIterations = 100000
+
+// MaxIterations the maximal number of PBKDF2 iterations accepted by Decrypt, so that crafted key can't
+// make decryption run for hours
+const MaxIterations = 10000000
+
+// ErrIncorrectPassphrase returned when private key can't be decrypted with given passphrase
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase")
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+type encryptedPrivateKeyInfo struct {
+	EncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// Encrypt encrypts DER encoded PKCS#8 private key, returns DER encoded EncryptedPrivateKeyInfo,
+// the key is encrypted with AES-256-CBC, encryption key derived with PBKDF2 with HMAC-SHA256
+func Encrypt(privateKey, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "failed to generate salt")
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, errors.Wrap(err, "failed to generate IV")
+	}
+
+	key := Key(passphrase, salt, DefaultIterations, 32, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padLen := aes.BlockSize - len(privateKey)%aes.BlockSize
+	encrypted := append(append([]byte{}, privateKey...), bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: DefaultIterations,
+		PRF: pkix.AlgorithmIdentifier{
+			Algorithm:  oidHMACWithSHA256,
+			Parameters: asn1.NullRawValue,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	schemeParams, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBKDF2,
+			Parameters: asn1.RawValue{FullBytes: kdfParams},
+		},
+		EncryptionScheme: pkix.AlgorithmIdentifier{
+			Algorithm:  oidAES256CBC,
+			Parameters: asn1.RawValue{FullBytes: ivParam},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		EncryptionAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBES2,
+			Parameters: asn1.RawValue{FullBytes: schemeParams},
+		},
+		EncryptedData: encrypted,
+	})
+}
+
+// Decrypt decrypts DER encoded EncryptedPrivateKeyInfo, returns DER encoded PKCS#8 private key.
+// ErrIncorrectPassphrase is returned if decrypted data is not valid, any other error means
+// encrypted key is malformed or encrypted with unsupported algorithm
+func Decrypt(encryptedKey, passphrase []byte) ([]byte, error) {
+	info := &encryptedPrivateKeyInfo{}
+	if rest, err := asn1.Unmarshal(encryptedKey, info); err != nil || len(rest) > 0 {
+		return nil, errors.New("failed to parse encrypted private key info")
+	}
+	if !info.EncryptionAlgorithm.Algorithm.Equal(oidPBES2) {
+		return nil, errors.Errorf("unsupported encryption scheme %s, only PBES2 is supported", info.EncryptionAlgorithm.Algorithm)
+	}
+
+	params := &pbes2Params{}
+	if _, err := asn1.Unmarshal(info.EncryptionAlgorithm.Parameters.FullBytes, params); err != nil {
+		return nil, errors.Wrap(err, "failed to parse PBES2 parameters")
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, errors.Errorf("unsupported key derivation function %s, only PBKDF2 is supported", params.KeyDerivationFunc.Algorithm)
+	}
+	kdfParams := &pbkdf2Params{}
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, kdfParams); err != nil {
+		return nil, errors.Wrap(err, "failed to parse PBKDF2 parameters")
+	}
+	if kdfParams.IterationCount < 1 || kdfParams.IterationCount > MaxIterations {
+		return nil, errors.Errorf("PBKDF2 iteration count %d is out of range, expected 1 to %d", kdfParams.IterationCount, MaxIterations)
+	}
+	prf, err := prfHash(kdfParams.PRF.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	keyLen, err := aesKeyLength(params.EncryptionScheme.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if kdfParams.KeyLength != 0 && kdfParams.KeyLength != keyLen {
+		return nil, errors.Errorf("PBKDF2 key length %d doesn't match encryption scheme %s", kdfParams.KeyLength, params.EncryptionScheme.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, errors.New("failed to parse AES-CBC initialization vector")
+	}
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, errors.New("encrypted data is not a multiple of the AES block size")
+	}
+
+	block, err := aes.NewCipher(Key(passphrase, kdfParams.Salt, kdfParams.IterationCount, keyLen, prf))
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	// invalid padding is the sign of the wrong key, since the data itself was parsed successfully
+	padLen := int(decrypted[len(decrypted)-1])
+	if padLen == 0 || padLen > aes.BlockSize {
+		return nil, ErrIncorrectPassphrase
+	}
+	for _, b := range decrypted[len(decrypted)-padLen:] {
+		if int(b) != padLen {
+			return nil, ErrIncorrectPassphrase
+		}
+	}
+	return decrypted[:len(decrypted)-padLen], nil
+}
+
+func prfHash(oid asn1.ObjectIdentifier) (func() hash.Hash, error) {
+	switch {
+	case len(oid) == 0, oid.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case oid.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	case oid.Equal(oidHMACWithSHA384):
+		return sha512.New384, nil
+	case oid.Equal(oidHMACWithSHA512):
+		return sha512.New, nil
+	default:
+		return nil, errors.Errorf("unsupported PBKDF2 pseudorandom function %s", oid)
+	}
+}
+
+func aesKeyLength(oid asn1.ObjectIdentifier) (int, error) {
+	switch {
+	case oid.Equal(oidAES128CBC):
+		return 16, nil
+	case oid.Equal(oidAES192CBC):
+		return 24, nil
+	case oid.Equal(oidAES256CBC):
+		return 32, nil
+	default:
+		return 0, errors.Errorf("unsupported encryption algorithm %s, only AES-CBC is supported", oid)
+	}
+}
+
+// Key derives key of keyLen bytes from the passphrase with PBKDF2, as defined by RFC 8018
+func Key(passphrase, salt []byte, iterations, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, passphrase)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	derived := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+	for blockNum := 1; blockNum <= numBlocks; blockNum++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(blockNum >> 24), byte(blockNum >> 16), byte(blockNum >> 8), byte(blockNum)})
+		derived = prf.Sum(derived)
+		t := derived[len(derived)-hashLen:]
+		copy(u, t)
+
+		for i := 2; i <= iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range u {
+				t[j] ^= u[j]
+			}
+		}
+	}
+	return derived[:keyLen]
+}
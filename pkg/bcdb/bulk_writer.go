@@ -0,0 +1,362 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultBulkWriterMaxTxOps        = 100
+	defaultBulkWriterMaxTxBytes      = 1024 * 1024
+	defaultBulkWriterMaxInFlight     = 4
+	defaultBulkWriterFlushInterval   = 100 * time.Millisecond
+	defaultBulkWriterResultsCapacity = 1000
+)
+
+// ErrBulkWriterClosed returned by BulkWriter.Write once the writer is closed
+var ErrBulkWriterClosed = errors.New("bulk writer is closed")
+
+// BulkWriterOptions controls batching and pipelining of DBSession.BulkWriter
+type BulkWriterOptions struct {
+	// MaxTxOps maximal number of operations packed into single transaction, 100 if 0
+	MaxTxOps int
+	// MaxTxBytes maximal total size of keys and values packed into single transaction, 1MB if 0.
+	// Operation larger than MaxTxBytes is submitted in its own transaction
+	MaxTxBytes int
+	// MaxInFlight maximal number of transactions submitted and waiting for receipts, 4 if 0.
+	// Write blocks once the limit is reached and the next transaction is full
+	MaxInFlight int
+	// FlushInterval partially filled transaction is submitted every FlushInterval, 100 milliseconds if 0
+	FlushInterval time.Duration
+	// ResultsCapacity capacity of the results channel, 1000 if 0
+	ResultsCapacity int
+}
+
+// BulkOp single operation of the bulk write, either put of the value with optional ACL or delete of the key
+type BulkOp struct {
+	DBName string
+	Key    string
+	Value  []byte
+	ACL    *types.AccessControl
+	// Delete deletes the key, Value and ACL are ignored
+	Delete bool
+}
+
+// BulkResult outcome of the operation written to BulkWriter
+type BulkResult struct {
+	// Op the operation passed to BulkWriter.Write
+	Op *BulkOp
+	// TxID ID of the transaction the operation was packed into, empty if the transaction
+	// wasn't created
+	TxID string
+	// Receipt receipt of the transaction, nil if the transaction wasn't committed
+	Receipt *types.TxReceipt
+	// Err nil if the transaction was committed and is valid. If transaction was invalidated
+	// by the server, Receipt returned along with *TxInvalidError
+	Err error
+}
+
+// BulkWriter packs stream of operations into data transactions and submits them asynchronously,
+// with bounded number of transactions in flight. Each transaction is tracked until its receipt is
+// available and the outcome of each operation is reported on the Results channel.
+// BulkWriter is safe for concurrent use
+type BulkWriter interface {
+	// Write queues operation, blocks while the maximal number of transactions are in flight.
+	// Returns ErrBulkWriterClosed once the writer is closed, the operation isn't reported in this case.
+	// Transaction with operation on the key of transaction in flight is submitted once the receipt of
+	// the latter is available, so operations on the same key are committed in the order they were written
+	Write(ctx context.Context, op *BulkOp) error
+	// Flush submits partially filled transaction without waiting for FlushInterval
+	Flush()
+	// Results returns channel of operation outcomes, closed once the writer is closed and all
+	// transactions completed. Results should be consumed concurrently with Write and Close,
+	// otherwise the writer blocks once the channel is full
+	Results() <-chan *BulkResult
+	// Close submits queued operations and waits until receipts of all transactions are available.
+	// Subsequent calls are no-op
+	Close() error
+}
+
+type bulkWriter struct {
+	ctx   context.Context
+	newTx func(ctx context.Context) (DataTxContext, error)
+
+	maxTxOps      int
+	maxTxBytes    int
+	flushInterval time.Duration
+
+	ops       chan *BulkOp
+	flush     chan struct{}
+	results   chan *BulkResult
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
+	inFlight  chan struct{}
+	pending   sync.WaitGroup
+	batch     []*BulkOp
+	batchKeys map[bulkOpKey]bool
+	batchSize int
+
+	// keysMu protects inFlightKeys, keys of transactions in flight mapped to the channel closed once
+	// the receipt of the transaction, which wrote the key last, is available
+	keysMu       sync.Mutex
+	inFlightKeys map[bulkOpKey]chan struct{}
+}
+
+type bulkOpKey struct {
+	dbName string
+	key    string
+}
+
+// newBulkWriter returns writer, which creates data transactions using newTx. Submission of transactions
+// is abandoned once ctx is done
+func newBulkWriter(ctx context.Context, newTx func(ctx context.Context) (DataTxContext, error), opts *BulkWriterOptions) *bulkWriter {
+	w := &bulkWriter{
+		ctx:           ctx,
+		newTx:         newTx,
+		maxTxOps:      defaultBulkWriterMaxTxOps,
+		maxTxBytes:    defaultBulkWriterMaxTxBytes,
+		flushInterval: defaultBulkWriterFlushInterval,
+		ops:           make(chan *BulkOp),
+		flush:         make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+		batchKeys:     make(map[bulkOpKey]bool),
+		inFlightKeys:  make(map[bulkOpKey]chan struct{}),
+	}
+	maxInFlight := defaultBulkWriterMaxInFlight
+	resultsCapacity := defaultBulkWriterResultsCapacity
+	if opts != nil {
+		if opts.MaxTxOps > 0 {
+			w.maxTxOps = opts.MaxTxOps
+		}
+		if opts.MaxTxBytes > 0 {
+			w.maxTxBytes = opts.MaxTxBytes
+		}
+		if opts.MaxInFlight > 0 {
+			maxInFlight = opts.MaxInFlight
+		}
+		if opts.FlushInterval > 0 {
+			w.flushInterval = opts.FlushInterval
+		}
+		if opts.ResultsCapacity > 0 {
+			resultsCapacity = opts.ResultsCapacity
+		}
+	}
+	w.inFlight = make(chan struct{}, maxInFlight)
+	w.results = make(chan *BulkResult, resultsCapacity)
+
+	go w.run()
+	return w
+}
+
+func (w *bulkWriter) Write(ctx context.Context, op *BulkOp) error {
+	if op == nil || op.DBName == "" || op.Key == "" {
+		return errors.New("bulk operation should have database name and key")
+	}
+
+	select {
+	case <-w.stop:
+		return ErrBulkWriterClosed
+	default:
+	}
+
+	select {
+	case w.ops <- op:
+		return nil
+	case <-w.stop:
+		return ErrBulkWriterClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *bulkWriter) Flush() {
+	select {
+	case w.flush <- struct{}{}:
+	default:
+	}
+}
+
+func (w *bulkWriter) Results() <-chan *BulkResult {
+	return w.results
+}
+
+func (w *bulkWriter) Close() error {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+	return w.ctx.Err()
+}
+
+// run packs written operations into transactions until the writer is closed
+func (w *bulkWriter) run() {
+	defer close(w.done)
+	defer close(w.results)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case op := <-w.ops:
+			w.add(op)
+		case <-w.flush:
+			w.submit()
+		case <-ticker.C:
+			w.submit()
+		case <-w.stop:
+			// pick up operations of writers racing with Close
+		drain:
+			for {
+				select {
+				case op := <-w.ops:
+					w.add(op)
+				default:
+					break drain
+				}
+			}
+			w.submit()
+			w.pending.Wait()
+			return
+		}
+	}
+}
+
+// add appends operation to the next transaction, the transaction is submitted first if the operation
+// doesn't fit into it or the transaction already contains operation on the same key
+func (w *bulkWriter) add(op *BulkOp) {
+	key := bulkOpKey{dbName: op.DBName, key: op.Key}
+	size := len(op.Key) + len(op.Value)
+	if len(w.batch) > 0 && (w.batchKeys[key] || w.batchSize+size > w.maxTxBytes) {
+		w.submit()
+	}
+
+	w.batch = append(w.batch, op)
+	w.batchKeys[key] = true
+	w.batchSize += size
+	if len(w.batch) >= w.maxTxOps || w.batchSize >= w.maxTxBytes {
+		w.submit()
+	}
+}
+
+// submit commits the next transaction asynchronously, blocks while the maximal number of transactions
+// are in flight or transaction in flight wrote one of the keys of the next transaction
+func (w *bulkWriter) submit() {
+	if len(w.batch) == 0 {
+		return
+	}
+	batch := w.batch
+	keys := w.batchKeys
+	w.batch = nil
+	w.batchKeys = make(map[bulkOpKey]bool)
+	w.batchSize = 0
+
+	if err := w.ctx.Err(); err != nil {
+		w.report(batch, "", nil, err)
+		return
+	}
+	if err := w.waitKeys(keys); err != nil {
+		w.report(batch, "", nil, err)
+		return
+	}
+	select {
+	case w.inFlight <- struct{}{}:
+	case <-w.ctx.Done():
+		w.report(batch, "", nil, w.ctx.Err())
+		return
+	}
+
+	txID, f, err := w.commit(batch)
+	if err != nil {
+		<-w.inFlight
+		w.report(batch, txID, nil, err)
+		return
+	}
+
+	done := make(chan struct{})
+	w.keysMu.Lock()
+	for key := range keys {
+		w.inFlightKeys[key] = done
+	}
+	w.keysMu.Unlock()
+
+	w.pending.Add(1)
+	go func() {
+		defer w.pending.Done()
+		receipt, err := f.Wait(w.ctx)
+
+		w.keysMu.Lock()
+		for key := range keys {
+			if w.inFlightKeys[key] == done {
+				delete(w.inFlightKeys, key)
+			}
+		}
+		w.keysMu.Unlock()
+		close(done)
+
+		<-w.inFlight
+		w.report(batch, txID, receipt, err)
+	}()
+}
+
+// waitKeys blocks until receipts of transactions in flight, which wrote any of the keys, are available
+func (w *bulkWriter) waitKeys(keys map[bulkOpKey]bool) error {
+	for key := range keys {
+		w.keysMu.Lock()
+		done := w.inFlightKeys[key]
+		w.keysMu.Unlock()
+		if done == nil {
+			continue
+		}
+		select {
+		case <-done:
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (w *bulkWriter) commit(batch []*BulkOp) (string, TxFuture, error) {
+	tx, err := w.newTx(w.ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, op := range batch {
+		if op.Delete {
+			err = tx.Delete(op.DBName, op.Key)
+		} else {
+			err = tx.Put(op.DBName, op.Key, op.Value, op.ACL)
+		}
+		if err != nil {
+			tx.Abort()
+			return "", nil, err
+		}
+	}
+
+	f, err := tx.CommitFuture(w.ctx, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return f.TxID(), f, nil
+}
+
+func (w *bulkWriter) report(batch []*BulkOp, txID string, receipt *types.TxReceipt, err error) {
+	for _, op := range batch {
+		w.results <- &BulkResult{
+			Op:      op,
+			TxID:    txID,
+			Receipt: receipt,
+			Err:     err,
+		}
+	}
+}
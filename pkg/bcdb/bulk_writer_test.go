@@ -0,0 +1,316 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBulkTxs creates data transactions, which record operations and commit with receipt returned by commit
+type fakeBulkTxs struct {
+	mu     sync.Mutex
+	txs    []*fakeBulkTx
+	commit func(tx *fakeBulkTx) TxFuture
+}
+
+func (f *fakeBulkTxs) newTx(ctx context.Context) (DataTxContext, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tx := &fakeBulkTx{txs: f, txID: fmt.Sprintf("tx%d", len(f.txs)+1)}
+	f.txs = append(f.txs, tx)
+	return tx, nil
+}
+
+func (f *fakeBulkTxs) keys() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var keys [][]string
+	for _, tx := range f.txs {
+		keys = append(keys, tx.keys)
+	}
+	return keys
+}
+
+type fakeBulkTx struct {
+	DataTxContext
+	txs     *fakeBulkTxs
+	txID    string
+	keys    []string
+	deletes []string
+}
+
+func (tx *fakeBulkTx) Put(dbName, key string, value []byte, acl *types.AccessControl) error {
+	tx.txs.mu.Lock()
+	defer tx.txs.mu.Unlock()
+
+	tx.keys = append(tx.keys, key)
+	return nil
+}
+
+func (tx *fakeBulkTx) Delete(dbName, key string) error {
+	tx.txs.mu.Lock()
+	defer tx.txs.mu.Unlock()
+
+	tx.keys = append(tx.keys, key)
+	tx.deletes = append(tx.deletes, key)
+	return nil
+}
+
+func (tx *fakeBulkTx) CommitFuture(ctx context.Context, sync bool) (TxFuture, error) {
+	if tx.txs.commit != nil {
+		return tx.txs.commit(tx), nil
+	}
+	return newResolvedTxFuture(tx.txID, receiptWithFlag(types.Flag_VALID, "")), nil
+}
+
+// collectBulkResults reads results until the channel is closed
+func collectBulkResults(w BulkWriter) <-chan []*BulkResult {
+	collected := make(chan []*BulkResult, 1)
+	go func() {
+		var results []*BulkResult
+		for res := range w.Results() {
+			results = append(results, res)
+		}
+		collected <- results
+	}()
+	return collected
+}
+
+func resultsByOp(results []*BulkResult) map[*BulkOp]*BulkResult {
+	byOp := make(map[*BulkOp]*BulkResult)
+	for _, res := range results {
+		byOp[res.Op] = res
+	}
+	return byOp
+}
+
+func TestBulkWriter(t *testing.T) {
+	t.Run("transactions packed up to count and size limits", func(t *testing.T) {
+		txs := &fakeBulkTxs{}
+		w := newBulkWriter(context.Background(), txs.newTx, &BulkWriterOptions{
+			MaxTxOps:      3,
+			MaxTxBytes:    10,
+			FlushInterval: time.Minute,
+		})
+		collected := collectBulkResults(w)
+
+		ops := []*BulkOp{
+			{DBName: "bdb", Key: "k1", Value: []byte("1")},
+			{DBName: "bdb", Key: "k2", Value: []byte("2")},
+			{DBName: "bdb", Key: "k3", Delete: true},
+			{DBName: "bdb", Key: "k4", Value: []byte("4")},
+			// same key as previous operation, submitted in the next transaction
+			{DBName: "bdb", Key: "k4", Value: []byte("5")},
+			// exceeds size limit
+			{DBName: "bdb", Key: "k6", Value: []byte("0123456789")},
+			{DBName: "bdb", Key: "k7", Value: []byte("7")},
+		}
+		for _, op := range ops {
+			require.NoError(t, w.Write(context.Background(), op))
+		}
+		require.NoError(t, w.Close())
+		require.NoError(t, w.Close())
+
+		require.Equal(t, [][]string{{"k1", "k2", "k3"}, {"k4"}, {"k4"}, {"k6"}, {"k7"}}, txs.keys())
+		require.Equal(t, []string{"k3"}, txs.txs[0].deletes)
+
+		// transactions complete in any order
+		results := resultsByOp(<-collected)
+		require.Len(t, results, len(ops))
+		for _, op := range ops {
+			require.NoError(t, results[op].Err)
+			require.NotNil(t, results[op].Receipt)
+		}
+		require.Equal(t, "tx1", results[ops[2]].TxID)
+		require.Equal(t, "tx5", results[ops[6]].TxID)
+
+		require.Equal(t, ErrBulkWriterClosed, w.Write(context.Background(), &BulkOp{DBName: "bdb", Key: "k8"}))
+	})
+
+	t.Run("partially filled transaction flushed", func(t *testing.T) {
+		txs := &fakeBulkTxs{}
+		w := newBulkWriter(context.Background(), txs.newTx, &BulkWriterOptions{
+			FlushInterval: 10 * time.Millisecond,
+		})
+		collected := collectBulkResults(w)
+
+		require.NoError(t, w.Write(context.Background(), &BulkOp{DBName: "bdb", Key: "k1"}))
+		require.Eventually(t, func() bool {
+			return len(txs.keys()) == 1
+		}, time.Second, 5*time.Millisecond)
+
+		require.NoError(t, w.Write(context.Background(), &BulkOp{DBName: "bdb", Key: "k2"}))
+		w.Flush()
+		require.Eventually(t, func() bool {
+			return len(txs.keys()) == 2
+		}, time.Second, 5*time.Millisecond)
+
+		require.NoError(t, w.Close())
+		require.Len(t, <-collected, 2)
+	})
+
+	t.Run("bounded transactions in flight", func(t *testing.T) {
+		release := make(chan struct{})
+		var mu sync.Mutex
+		inFlight, maxInFlight := 0, 0
+		txs := &fakeBulkTxs{commit: func(tx *fakeBulkTx) TxFuture {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			return newPollingTxFuture(tx.txID, func(ctx context.Context, txID string) (*types.TxReceipt, error) {
+				select {
+				case <-release:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				return receiptWithFlag(types.Flag_VALID, ""), nil
+			}, nil, nil)
+		}}
+		w := newBulkWriter(context.Background(), txs.newTx, &BulkWriterOptions{
+			MaxTxOps:    1,
+			MaxInFlight: 2,
+		})
+		collected := collectBulkResults(w)
+
+		// third transaction waits for the first two
+		for i := 0; i < 3; i++ {
+			require.NoError(t, w.Write(context.Background(), &BulkOp{DBName: "bdb", Key: fmt.Sprintf("k%d", i)}))
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.Equal(t, context.DeadlineExceeded, w.Write(ctx, &BulkOp{DBName: "bdb", Key: "k3"}))
+		require.Len(t, txs.keys(), 2)
+
+		close(release)
+		require.NoError(t, w.Close())
+		require.Len(t, <-collected, 3)
+		require.Len(t, txs.keys(), 3)
+		require.Equal(t, 2, maxInFlight)
+	})
+
+	t.Run("same key transactions committed in order", func(t *testing.T) {
+		releases := map[string]chan struct{}{
+			"tx1": make(chan struct{}),
+			"tx2": make(chan struct{}),
+		}
+		var mu sync.Mutex
+		var committed []string
+		txs := &fakeBulkTxs{commit: func(tx *fakeBulkTx) TxFuture {
+			return newPollingTxFuture(tx.txID, func(ctx context.Context, txID string) (*types.TxReceipt, error) {
+				select {
+				case <-releases[txID]:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				mu.Lock()
+				committed = append(committed, txID)
+				mu.Unlock()
+				return receiptWithFlag(types.Flag_VALID, ""), nil
+			}, &config.ReceiptPollingConfig{InitialInterval: time.Millisecond}, nil)
+		}}
+		w := newBulkWriter(context.Background(), txs.newTx, &BulkWriterOptions{
+			MaxInFlight:   4,
+			FlushInterval: time.Minute,
+		})
+		collected := collectBulkResults(w)
+
+		// second operation on k1 submits the first transaction, the second one waits for its receipt
+		require.NoError(t, w.Write(context.Background(), &BulkOp{DBName: "bdb", Key: "k1", Value: []byte("1")}))
+		require.NoError(t, w.Write(context.Background(), &BulkOp{DBName: "bdb", Key: "k1", Value: []byte("2")}))
+		require.NoError(t, w.Write(context.Background(), &BulkOp{DBName: "bdb", Key: "k2", Value: []byte("2")}))
+		w.Flush()
+		time.Sleep(50 * time.Millisecond)
+		require.Equal(t, [][]string{{"k1"}}, txs.keys())
+
+		close(releases["tx1"])
+		require.Eventually(t, func() bool {
+			return len(txs.keys()) == 2
+		}, time.Second, 5*time.Millisecond)
+		require.Equal(t, [][]string{{"k1"}, {"k1", "k2"}}, txs.keys())
+
+		close(releases["tx2"])
+		require.NoError(t, w.Close())
+		require.Len(t, <-collected, 3)
+		require.Equal(t, []string{"tx1", "tx2"}, committed)
+	})
+
+	t.Run("failures reported per operation", func(t *testing.T) {
+		txs := &fakeBulkTxs{commit: func(tx *fakeBulkTx) TxFuture {
+			if tx.txID == "tx1" {
+				return newResolvedTxFuture(tx.txID, receiptWithFlag(types.Flag_INVALID_NO_PERMISSION, "no write permission"))
+			}
+			return newResolvedTxFuture(tx.txID, receiptWithFlag(types.Flag_VALID, ""))
+		}}
+		// third transaction can't be created
+		newTxErr := errors.New("session is gone")
+		w := newBulkWriter(context.Background(), func(ctx context.Context) (DataTxContext, error) {
+			if len(txs.keys()) == 2 {
+				return nil, newTxErr
+			}
+			return txs.newTx(ctx)
+		}, &BulkWriterOptions{
+			MaxTxOps:      2,
+			FlushInterval: time.Minute,
+		})
+		collected := collectBulkResults(w)
+
+		require.EqualError(t, w.Write(context.Background(), &BulkOp{Key: "k1"}), "bulk operation should have database name and key")
+		var ops []*BulkOp
+		for i := 0; i < 5; i++ {
+			op := &BulkOp{DBName: "bdb", Key: fmt.Sprintf("k%d", i)}
+			ops = append(ops, op)
+			require.NoError(t, w.Write(context.Background(), op))
+		}
+		require.NoError(t, w.Close())
+
+		results := resultsByOp(<-collected)
+		require.Len(t, results, 5)
+		for _, op := range ops[:2] {
+			invalidErr := &TxInvalidError{}
+			require.True(t, errors.As(results[op].Err, &invalidErr))
+			require.True(t, errors.Is(results[op].Err, ErrPermissionDenied))
+			require.Equal(t, "tx1", results[op].TxID)
+			require.NotNil(t, results[op].Receipt)
+		}
+		for _, op := range ops[2:4] {
+			require.NoError(t, results[op].Err)
+			require.Equal(t, "tx2", results[op].TxID)
+		}
+		require.Equal(t, newTxErr, results[ops[4]].Err)
+		require.Empty(t, results[ops[4]].TxID)
+		require.Nil(t, results[ops[4]].Receipt)
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		txs := &fakeBulkTxs{}
+		w := newBulkWriter(ctx, txs.newTx, &BulkWriterOptions{FlushInterval: time.Minute})
+		collected := collectBulkResults(w)
+
+		require.NoError(t, w.Write(context.Background(), &BulkOp{DBName: "bdb", Key: "k1"}))
+		cancel()
+		require.Equal(t, context.Canceled, w.Close())
+
+		results := <-collected
+		require.Len(t, results, 1)
+		require.Equal(t, context.Canceled, results[0].Err)
+		require.Empty(t, txs.keys())
+	})
+}
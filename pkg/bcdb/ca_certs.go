@@ -0,0 +1,74 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// caCertPools keeps CA certificates used to verify the certificates of the nodes
+type caCertPools struct {
+	// roots all configured CA certificates, trust anchors
+	roots *x509.CertPool
+	// intermediates configured CA certificates issued by other CAs, used to build the chain to one of the
+	// self-signed roots
+	intermediates *x509.CertPool
+}
+
+// verifyOptions returns options to verify certificate against CA pools
+func (p *caCertPools) verifyOptions() x509.VerifyOptions {
+	return x509.VerifyOptions{
+		Roots:         p.roots,
+		Intermediates: p.intermediates,
+	}
+}
+
+// loadCACertificates reads all PEM encoded certificates from each file, each file may contain a bundle of
+// root and intermediate CA certificates. Each certificate is a trust anchor and is added to the root pool,
+// certificates which aren't self-signed are also added to the intermediate pool
+func loadCACertificates(paths []string) (*caCertPools, error) {
+	pools := &caCertPools{
+		roots:         x509.NewCertPool(),
+		intermediates: x509.NewCertPool(),
+	}
+	for _, caPath := range paths {
+		caBytes, err := ioutil.ReadFile(caPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read CA certificate file %s", caPath)
+		}
+
+		blockNum := 0
+		for rest := caBytes; ; {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			blockNum++
+			if block.Type != "CERTIFICATE" {
+				return nil, errors.Errorf("unexpected PEM block type %s in block %d of CA certificate file %s", block.Type, blockNum, caPath)
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse X509 certificate in block %d of CA certificate file %s", blockNum, caPath)
+			}
+			pools.roots.AddCert(cert)
+			if !isSelfSigned(cert) {
+				pools.intermediates.AddCert(cert)
+			}
+		}
+		if blockNum == 0 {
+			return nil, errors.Errorf("no PEM encoded certificates found in CA certificate file %s", caPath)
+		}
+	}
+	return pools, nil
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
@@ -0,0 +1,132 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCACertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rootCA := newTestCA(t, "root CA")
+	intermediateCert, intermediateKey := rootCA.issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "intermediate CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	})
+	intermediateCA := &testCA{cert: intermediateCert, key: intermediateKey}
+	nodeCert, _ := intermediateCA.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "node1"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	otherCA := newTestCA(t, "other CA")
+
+	t.Run("bundle with intermediate CA", func(t *testing.T) {
+		bundlePath := writeTestPEM(t, dir, "bundle.pem", certPEMBlock(intermediateCert), certPEMBlock(rootCA.cert))
+		otherPath := writeTestPEM(t, dir, "other.pem", certPEMBlock(otherCA.cert))
+
+		pools, err := loadCACertificates([]string{otherPath, bundlePath})
+		require.NoError(t, err)
+		chains, err := nodeCert.Verify(pools.verifyOptions())
+		require.NoError(t, err)
+		var lengths []int
+		for _, chain := range chains {
+			lengths = append(lengths, len(chain))
+		}
+		require.ElementsMatch(t, []int{2, 3}, lengths)
+	})
+
+	t.Run("intermediate CA only", func(t *testing.T) {
+		intermediatePath := writeTestPEM(t, dir, "intermediate.pem", certPEMBlock(intermediateCert))
+		pools, err := loadCACertificates([]string{intermediatePath})
+		require.NoError(t, err)
+		chains, err := nodeCert.Verify(pools.verifyOptions())
+		require.NoError(t, err)
+		require.Len(t, chains, 1)
+		require.Equal(t, intermediateCert.Raw, chains[0][1].Raw)
+
+		_, err = rootCA.cert.Verify(pools.verifyOptions())
+		require.Error(t, err)
+	})
+
+	t.Run("intermediate CA missing", func(t *testing.T) {
+		rootPath := writeTestPEM(t, dir, "root.pem", certPEMBlock(rootCA.cert))
+		pools, err := loadCACertificates([]string{rootPath})
+		require.NoError(t, err)
+		_, err = nodeCert.Verify(pools.verifyOptions())
+		require.Error(t, err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := loadCACertificates([]string{path.Join(dir, "missing.pem")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to read CA certificate file "+path.Join(dir, "missing.pem"))
+
+		emptyPath := path.Join(dir, "empty.pem")
+		require.NoError(t, ioutil.WriteFile(emptyPath, []byte("no certificates here"), 0600))
+		_, err = loadCACertificates([]string{emptyPath})
+		require.EqualError(t, err, "no PEM encoded certificates found in CA certificate file "+emptyPath)
+
+		keyPath := writeTestPEM(t, dir, "key.pem", certPEMBlock(rootCA.cert), keyPEMBlock(t, rootCA.key))
+		_, err = loadCACertificates([]string{keyPath})
+		require.EqualError(t, err, "unexpected PEM block type EC PRIVATE KEY in block 2 of CA certificate file "+keyPath)
+
+		corruptedPath := writeTestPEM(t, dir, "corrupted.pem", certPEMBlock(rootCA.cert), &pem.Block{Type: "CERTIFICATE", Bytes: []byte{1, 2, 3}})
+		_, err = loadCACertificates([]string{corruptedPath})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to parse X509 certificate in block 2 of CA certificate file "+corruptedPath)
+	})
+}
+
+func TestSession_IntermediateCAOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rootCA := newTestCA(t, "root CA")
+	intermediateCert, intermediateKey := rootCA.issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "intermediate CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	})
+	intermediateCA := &testCA{cert: intermediateCert, key: intermediateKey}
+	nodeCert, nodeKey := intermediateCA.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "node1"}})
+	node := &testNode{id: "node1", cert: nodeCert, key: nodeKey}
+
+	server := newSignedResponseServer(t, func(r *http.Request) (proto.Message, *testNode) {
+		if r.URL.Path == constants.URLForGetConfig() {
+			return testClusterConfigResponse(node), node
+		}
+		return nil, nil
+	})
+	defer server.Close()
+
+	// only the intermediate CA, which issued the node certificate, is configured
+	db, err := Create(&config.ConnectionConfig{
+		ReplicaSet: []*config.Replica{{ID: "node1", Endpoint: server.URL}},
+		RootCAs:    []string{writeTestPEM(t, dir, "intermediate.pem", certPEMBlock(intermediateCert))},
+		Logger:     createTestLogger(t),
+	})
+	require.NoError(t, err)
+	session, err := db.Session(testSessionConfig(t, intermediateCA))
+	require.NoError(t, err)
+	_, err = session.DataTx()
+	require.NoError(t, err)
+}
@@ -0,0 +1,190 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/server/testutils"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession_ConcurrentUse(t *testing.T) {
+	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "server"})
+	testServer, _, _, err := SetupTestServerWithParams(t, clientCertTemDir, 100*time.Millisecond, 10)
+	defer testServer.Stop()
+	require.NoError(t, err)
+	_, _, aliceSession := startServerConnectOpenAdminCreateUserAndUserSession(t, testServer, clientCertTemDir, "alice")
+
+	const goroutines = 10
+	const iterations = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*iterations)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				if err := useSession(aliceSession, g, i); err != nil {
+					errs <- err
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	for g := 0; g < goroutines; g++ {
+		for i := 0; i < iterations; i++ {
+			key := fmt.Sprintf("key-%d-%d", g, i)
+			validateValue(t, key, key, aliceSession)
+		}
+	}
+}
+
+// useSession runs data transaction and queries ledger and provenance, node certificates are
+// refreshed concurrently by other goroutines
+func useSession(session DBSession, g, i int) error {
+	if i%3 == 0 {
+		session.(*dbSession).nodesCerts.invalidate()
+	}
+	key := fmt.Sprintf("key-%d-%d", g, i)
+	tx, err := session.DataTx()
+	if err != nil {
+		return err
+	}
+	if _, _, err = tx.Get("bdb", key); err != nil {
+		return err
+	}
+	if err = tx.Put("bdb", key, []byte(key), nil); err != nil {
+		return err
+	}
+	if _, _, err = tx.Commit(true); err != nil {
+		return err
+	}
+	if _, err = session.Ledger(); err != nil {
+		return err
+	}
+	_, err = session.Provenance()
+	return err
+}
+
+func TestTxContext_ConcurrentUse(t *testing.T) {
+	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "bob", "server"})
+	testServer, _, _, err := SetupTestServerWithParams(t, clientCertTemDir, 100*time.Millisecond, 10)
+	defer testServer.Stop()
+	require.NoError(t, err)
+	bcdb, adminSession, aliceSession := startServerConnectOpenAdminCreateUserAndUserSession(t, testServer, clientCertTemDir, "alice")
+	pemUserCert, err := ioutil.ReadFile(path.Join(clientCertTemDir, "bob.pem"))
+	require.NoError(t, err)
+	addUser(t, "bob", adminSession, pemUserCert, map[string]types.Privilege_Access{"bdb": 1})
+	bobSession := openUserSession(t, bcdb, "bob", clientCertTemDir)
+
+	tx, err := aliceSession.DataTx()
+	require.NoError(t, err)
+
+	const goroutines = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 4*goroutines)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key-%d", g)
+			if err := tx.Put("bdb", key, []byte(key), nil); err != nil {
+				errs <- err
+				return
+			}
+			value, _, err := tx.Get("bdb", key)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if string(value) != key {
+				errs <- fmt.Errorf("key %s has value %s in pending transaction", key, value)
+				return
+			}
+			if _, _, err = tx.Get("bdb", "shared"); err != nil {
+				errs <- err
+				return
+			}
+			tx.AddMustSignUser("bob")
+		}(g)
+	}
+	wg.Wait()
+
+	// Put racing with envelope signing either makes it into the transaction or fails with ErrTxSpent
+	written := map[string]bool{}
+	var writtenMu sync.Mutex
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			key := fmt.Sprintf("late-key-%d", g)
+			err := tx.Put("bdb", key, []byte(key), nil)
+			switch err {
+			case nil:
+				writtenMu.Lock()
+				written[key] = true
+				writtenMu.Unlock()
+			case ErrTxSpent:
+			default:
+				errs <- err
+			}
+		}(g)
+	}
+	env, err := tx.SignConstructedTxEnvelopeAndCloseTx()
+	require.NoError(t, err)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	_, _, err = tx.Commit(false)
+	require.Equal(t, ErrTxSpent, err)
+	tx.AddMustSignUser("charlie")
+
+	dataTx := env.(*types.DataTxEnvelope).GetPayload()
+	dbOps := dataTx.GetDBOperations()
+	require.Len(t, dbOps, 1)
+	var keys []string
+	for _, w := range dbOps[0].GetDataWrites() {
+		keys = append(keys, w.GetKey())
+	}
+	var expected []string
+	for g := 0; g < goroutines; g++ {
+		expected = append(expected, fmt.Sprintf("key-%d", g))
+	}
+	for key := range written {
+		expected = append(expected, key)
+	}
+	sort.Strings(keys)
+	sort.Strings(expected)
+	require.Equal(t, expected, keys)
+	require.Len(t, dbOps[0].GetDataReads(), 1)
+	require.Equal(t, []string{"alice", "bob"}, dataTx.GetMustSignUserIDs())
+
+	bobTx, err := bobSession.LoadDataTx(env.(*types.DataTxEnvelope))
+	require.NoError(t, err)
+	bobEnv, err := bobTx.CoSignTxEnvelopeAndCloseTx()
+	require.NoError(t, err)
+	loadedTx, err := aliceSession.LoadDataTx(bobEnv.(*types.DataTxEnvelope))
+	require.NoError(t, err)
+	_, _, err = loadedTx.Commit(true)
+	require.NoError(t, err)
+
+	for _, key := range expected {
+		validateValue(t, key, key, aliceSession)
+	}
+}
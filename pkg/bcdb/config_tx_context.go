@@ -3,6 +3,8 @@
 package bcdb
 
 import (
+	"context"
+
 	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/cryptoservice"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
@@ -49,14 +51,62 @@ type configTxContext struct {
 }
 
 func (c *configTxContext) Commit(sync bool) (string, *types.TxReceipt, error) {
-	return c.commit(c, constants.PostConfigTx, sync)
+	return c.CommitCtx(context.Background(), sync)
+}
+
+func (c *configTxContext) CommitCtx(ctx context.Context, sync bool) (string, *types.TxReceipt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	membershipChanged := c.membershipChanged()
+	txID, receipt, err := c.commit(ctx, c, constants.PostConfigTx, sync)
+	if err == nil {
+		c.configCommitted(receipt != nil, membershipChanged)
+	}
+	return txID, receipt, err
+}
+
+func (c *configTxContext) CommitFuture(ctx context.Context, sync bool) (TxFuture, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	membershipChanged := c.membershipChanged()
+	future, err := c.commitFuture(ctx, c, constants.PostConfigTx, sync)
+	if err == nil {
+		whenDone(future, func(_ *types.TxReceipt, err error) {
+			c.configCommitted(err == nil, membershipChanged)
+		})
+	}
+	return future, err
+}
+
+// configCommitted drops node certificates cached by the session, as config transaction could change
+// them, and refreshes nodes discovery if cluster nodes were changed and transaction was committed
+func (c *configTxContext) configCommitted(committed, membershipChanged bool) {
+	if c.nodesCertsCache != nil {
+		c.nodesCertsCache.invalidate()
+	}
+	if committed && membershipChanged {
+		c.discovery.trigger()
+	}
+}
+
+// membershipChanged checks whenever pending config changes the cluster nodes and nodes discovery should be refreshed
+func (c *configTxContext) membershipChanged() bool {
+	return c.discovery != nil && c.newConfig != nil && nodesChanged(c.oldConfig.GetNodes(), c.newConfig.GetNodes())
 }
 
 func (c *configTxContext) Abort() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	return c.abort(c)
 }
 
 func (c *configTxContext) AddAdmin(admin *types.Admin) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.txSpent {
 		return ErrTxSpent
 	}
@@ -79,6 +129,9 @@ func (c *configTxContext) AddAdmin(admin *types.Admin) (err error) {
 }
 
 func (c *configTxContext) DeleteAdmin(adminID string) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.txSpent {
 		return ErrTxSpent
 	}
@@ -109,6 +162,9 @@ func (c *configTxContext) DeleteAdmin(adminID string) (err error) {
 }
 
 func (c *configTxContext) UpdateAdmin(admin *types.Admin) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.txSpent {
 		return ErrTxSpent
 	}
@@ -133,6 +189,9 @@ func (c *configTxContext) UpdateAdmin(admin *types.Admin) (err error) {
 }
 
 func (c *configTxContext) AddClusterNode(node *types.NodeConfig, peer *types.PeerConfig) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.txSpent {
 		return ErrTxSpent
 	}
@@ -169,6 +228,9 @@ func (c *configTxContext) AddClusterNode(node *types.NodeConfig, peer *types.Pee
 }
 
 func (c *configTxContext) DeleteClusterNode(nodeID string) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.txSpent {
 		return ErrTxSpent
 	}
@@ -215,6 +277,9 @@ func (c *configTxContext) DeleteClusterNode(nodeID string) (err error) {
 }
 
 func (c *configTxContext) UpdateClusterNode(node *types.NodeConfig, peer *types.PeerConfig) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.txSpent {
 		return ErrTxSpent
 	}
@@ -252,6 +317,9 @@ func (c *configTxContext) UpdateClusterNode(node *types.NodeConfig, peer *types.
 }
 
 func (c *configTxContext) GetClusterConfig() (*types.ClusterConfig, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.txSpent {
 		return nil, ErrTxSpent
 	}
@@ -259,14 +327,14 @@ func (c *configTxContext) GetClusterConfig() (*types.ClusterConfig, error) {
 	return proto.Clone(c.oldConfig).(*types.ClusterConfig), nil
 }
 
-func (c *configTxContext) queryClusterConfig() error {
+func (c *configTxContext) queryClusterConfig(ctx context.Context) error {
 	if c.oldConfig != nil {
 		return nil
 	}
 
 	configResponse := &types.GetConfigResponse{}
 	path := constants.URLForGetConfig()
-	err := c.handleRequest(path, &types.GetConfigQuery{UserID: c.userID}, configResponse)
+	err := c.handleRequest(ctx, path, &types.GetConfigQuery{UserID: c.userID}, configResponse)
 	if err != nil {
 		c.logger.Errorf("failed to execute cluster config query path %s, due to %s", path, err)
 		return err
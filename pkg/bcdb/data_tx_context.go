@@ -3,6 +3,8 @@
 package bcdb
 
 import (
+	"context"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/cryptoservice"
@@ -14,27 +16,61 @@ type DataTxContext interface {
 	TxContext
 	// Put new value to key
 	Put(dbName string, key string, value []byte, acl *types.AccessControl) error
-	// Get existing key value
+	// Get existing key value. If key was written or deleted in this transaction, pending value
+	// returned, or nil if key deletion is pending, unless session configured with strict snapshot reads.
+	// Metadata of pending value contains only its ACL, version assigned during commit
 	Get(dbName, key string) ([]byte, *types.Metadata, error)
+	// GetCtx same as Get, ctx controls the request sent to the server
+	GetCtx(ctx context.Context, dbName, key string) ([]byte, *types.Metadata, error)
 	// Delete value for key
 	Delete(dbName, key string) error
+	// AddMustSignUser adds user to the list of users that must sign the transaction,
+	// the session user is always part of the list
+	AddMustSignUser(userID string)
+	// SignConstructedTxEnvelopeAndCloseTx returns transaction envelope signed by the session user,
+	// to be co-signed by the rest of the users that must sign it, see DBSession.LoadDataTx.
+	// The context is closed, no further operations are allowed
+	SignConstructedTxEnvelopeAndCloseTx() (proto.Message, error)
 }
 
 type dataTxContext struct {
 	*commonTxContext
-	operations map[string]*dbOperations
+	operations    map[string]*dbOperations
+	mustSignUsers []string
+	// strictSnapshot if true, Get ignores pending writes and deletes
+	strictSnapshot bool
 }
 
 func (d *dataTxContext) Commit(sync bool) (string, *types.TxReceipt, error) {
-	return d.commit(d, constants.PostDataTx, sync)
+	return d.CommitCtx(context.Background(), sync)
+}
+
+func (d *dataTxContext) CommitCtx(ctx context.Context, sync bool) (string, *types.TxReceipt, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.commit(ctx, d, constants.PostDataTx, sync)
+}
+
+func (d *dataTxContext) CommitFuture(ctx context.Context, sync bool) (TxFuture, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.commitFuture(ctx, d, constants.PostDataTx, sync)
 }
 
 func (d *dataTxContext) Abort() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	return d.abort(d)
 }
 
 // Put new value to key
 func (d *dataTxContext) Put(dbName, key string, value []byte, acl *types.AccessControl) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if d.txSpent {
 		return ErrTxSpent
 	}
@@ -59,14 +95,29 @@ func (d *dataTxContext) Put(dbName, key string, value []byte, acl *types.AccessC
 
 // Get existing key value
 func (d *dataTxContext) Get(dbName, key string) ([]byte, *types.Metadata, error) {
+	return d.GetCtx(context.Background(), dbName, key)
+}
+
+func (d *dataTxContext) GetCtx(ctx context.Context, dbName, key string) ([]byte, *types.Metadata, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if d.txSpent {
 		return nil, nil, ErrTxSpent
 	}
 
 	// TODO For this version, we support only single version read, each sequential read to same key will return same value
-	// TODO We ignore dirty reads for now - no check if key is already part of  d.dataWrites and/or d.dataDeletes, should be handled later
-	// Is key already read?
 	ops, ok := d.operations[dbName]
+	if ok && !d.strictSnapshot {
+		// Is key already written or deleted? Pending value is not added to the read set
+		if write, ok := ops.dataWrites[key]; ok {
+			return write.GetValue(), &types.Metadata{AccessControl: write.GetACL()}, nil
+		}
+		if _, ok := ops.dataDeletes[key]; ok {
+			return nil, nil, nil
+		}
+	}
+	// Is key already read?
 	if ok {
 		if storedValue, ok := ops.dataReads[key]; ok {
 			return storedValue.GetValue(), storedValue.GetMetadata(), nil
@@ -75,7 +126,7 @@ func (d *dataTxContext) Get(dbName, key string) ([]byte, *types.Metadata, error)
 
 	path := constants.URLForGetData(dbName, key)
 	res := &types.GetDataResponse{}
-	err := d.handleRequest(path, &types.GetDataQuery{
+	err := d.handleRequest(ctx, path, &types.GetDataQuery{
 		UserID: d.userID,
 		DBName: dbName,
 		Key:    key,
@@ -95,6 +146,9 @@ func (d *dataTxContext) Get(dbName, key string) ([]byte, *types.Metadata, error)
 
 // Delete value for key
 func (d *dataTxContext) Delete(dbName, key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if d.txSpent {
 		return ErrTxSpent
 	}
@@ -115,6 +169,51 @@ func (d *dataTxContext) Delete(dbName, key string) error {
 	return nil
 }
 
+// AddMustSignUser adds user to the list of users that must sign the transaction,
+// ignored once the context is spent
+func (d *dataTxContext) AddMustSignUser(userID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.txSpent || userID == d.userID {
+		return
+	}
+	for _, u := range d.mustSignUsers {
+		if u == userID {
+			return
+		}
+	}
+	d.mustSignUsers = append(d.mustSignUsers, userID)
+}
+
+// SignConstructedTxEnvelopeAndCloseTx composes transaction envelope signed by the session user only,
+// envelope should be passed to the rest of must sign users
+func (d *dataTxContext) SignConstructedTxEnvelopeAndCloseTx() (proto.Message, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.txSpent {
+		return nil, ErrTxSpent
+	}
+
+	txID, err := d.getOrComputeTxID()
+	if err != nil {
+		return nil, err
+	}
+
+	d.logger.Debugf("compose transaction enveloped with txID = %s", txID)
+	env, err := d.composeEnvelope(txID)
+	if err != nil {
+		d.logger.Errorf("failed to compose transaction envelope, due to %s", err)
+		return nil, err
+	}
+
+	d.txEnvelope = env
+	d.txSpent = true
+	d.cleanCtx()
+	return env, nil
+}
+
 func (d *dataTxContext) composeEnvelope(txID string) (proto.Message, error) {
 	var dbOperations []*types.DBOperation
 
@@ -142,7 +241,7 @@ func (d *dataTxContext) composeEnvelope(txID string) (proto.Message, error) {
 	}
 
 	payload := &types.DataTx{
-		MustSignUserIDs: []string{d.userID},
+		MustSignUserIDs: append([]string{d.userID}, d.mustSignUsers...),
 		TxID:            txID,
 		DBOperations:    dbOperations,
 	}
@@ -162,6 +261,7 @@ func (d *dataTxContext) composeEnvelope(txID string) (proto.Message, error) {
 
 func (d *dataTxContext) cleanCtx() {
 	d.operations = map[string]*dbOperations{}
+	d.mustSignUsers = nil
 }
 
 type dbOperations struct {
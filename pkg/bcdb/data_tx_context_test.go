@@ -3,10 +3,14 @@
 package bcdb
 
 import (
+	"context"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"path"
+	"sync"
 	"testing"
 	"time"
 
@@ -256,11 +260,52 @@ func TestDataContext_MultipleGetForSameKeyInTxAndMVCCConflict(t *testing.T) {
 	require.Equal(t, storedReadUpdated, storedRead)
 	require.NoError(t, err)
 	_, receipt, err := tx.Commit(true)
-	require.NoError(t, err)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrMVCCConflict))
+	invalidErr := &TxInvalidError{}
+	require.True(t, errors.As(err, &invalidErr))
+	require.Equal(t, types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE, invalidErr.Flag)
 	require.NotNil(t, receipt)
 	require.Equal(t, receipt.GetHeader().GetValidationInfo()[int(receipt.GetTxIndex())].GetFlag(), types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE)
 }
 
+func TestDataContext_RunDataTxRetryOnMVCCConflict(t *testing.T) {
+	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "server"})
+	testServer, _, _, err := SetupTestServer(t, clientCertTemDir)
+	defer testServer.Stop()
+	require.NoError(t, err)
+	testServer.Start()
+
+	bcdb, adminSession := connectAndOpenAdminSession(t, testServer, clientCertTemDir)
+	pemUserCert, err := ioutil.ReadFile(path.Join(clientCertTemDir, "alice.pem"))
+	require.NoError(t, err)
+	dbPerm := map[string]types.Privilege_Access{
+		"bdb": 1,
+	}
+	addUser(t, "alice", adminSession, pemUserCert, dbPerm)
+	userSession := openUserSession(t, bcdb, "alice", clientCertTemDir)
+
+	putKeySync(t, "bdb", "key1", "value1", "alice", userSession)
+
+	attempts := 0
+	_, receipt, err := userSession.RunDataTx(context.Background(), func(tx DataTxContext) error {
+		attempts++
+		val, _, err := tx.Get("bdb", "key1")
+		if err != nil {
+			return err
+		}
+		if attempts == 1 {
+			// Concurrent update of the read key, first attempt invalidated due to MVCC conflict
+			putKeySync(t, "bdb", "key1", "value2", "alice", userSession)
+		}
+		return tx.Put("bdb", "key1", append(val, []byte("-updated")...), nil)
+	}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, 2, attempts)
+	validateValue(t, "key1", "value2-updated", userSession)
+}
+
 func TestDataContext_GetUserPermissions(t *testing.T) {
 	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "bob", "server"})
 	testServer, _, _, err := SetupTestServer(t, clientCertTemDir)
@@ -288,6 +333,11 @@ func TestDataContext_GetUserPermissions(t *testing.T) {
 	_, _, err = tx.Get("bdb", "key1")
 	require.Error(t, err)
 	require.EqualError(t, err, "error handling request, server returned: status: 403 Forbidden, message: error while processing 'GET /data/bdb/key1' because the user [bob] has no permission to read key [key1] from database [bdb]")
+	require.True(t, errors.Is(err, ErrPermissionDenied))
+	serverErr := &ServerError{}
+	require.True(t, errors.As(err, &serverErr))
+	require.Equal(t, http.StatusForbidden, serverErr.StatusCode)
+	require.NotEmpty(t, serverErr.ReplicaID)
 	err = tx.Abort()
 	require.NoError(t, err)
 
@@ -384,6 +434,152 @@ func addUser(t *testing.T, userName string, session DBSession, pemUserCert []byt
 	require.Equal(t, userName, user.GetID())
 }
 
+func TestDataContext_CommitFuture(t *testing.T) {
+	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "server"})
+	testServer, _, _, err := SetupTestServer(t, clientCertTemDir)
+	defer testServer.Stop()
+	require.NoError(t, err)
+	_, _, aliceSession := startServerConnectOpenAdminCreateUserAndUserSession(t, testServer, clientCertTemDir, "alice")
+
+	var futures []TxFuture
+	for i := 0; i < 5; i++ {
+		tx, err := aliceSession.DataTx()
+		require.NoError(t, err)
+		err = tx.Put("bdb", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i)), nil)
+		require.NoError(t, err)
+
+		f, err := tx.CommitFuture(context.Background(), false)
+		require.NoError(t, err)
+		require.NotEmpty(t, f.TxID())
+		futures = append(futures, f)
+	}
+
+	for _, f := range futures {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		receipt, err := f.Wait(ctx)
+		cancel()
+		require.NoError(t, err)
+		require.NotNil(t, receipt)
+		require.Equal(t, types.Flag_VALID, receipt.GetHeader().GetValidationInfo()[receipt.GetTxIndex()].GetFlag())
+	}
+
+	for i := 0; i < 5; i++ {
+		validateValue(t, fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i), aliceSession)
+	}
+}
+
+func TestDataContext_ConcurrentSession(t *testing.T) {
+	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "server"})
+	testServer, _, _, err := SetupTestServerWithParams(t, clientCertTemDir, 100*time.Millisecond, 10)
+	defer testServer.Stop()
+	require.NoError(t, err)
+	_, _, aliceSession := startServerConnectOpenAdminCreateUserAndUserSession(t, testServer, clientCertTemDir, "alice")
+
+	const goroutines = 10
+	const iterations = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*iterations)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				key := fmt.Sprintf("key-%d-%d", g, i)
+				tx, err := aliceSession.DataTx()
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if err = tx.Put("bdb", key, []byte(key), nil); err != nil {
+					errs <- err
+					continue
+				}
+				f, err := tx.CommitFuture(context.Background(), false)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				_, err = f.Wait(ctx)
+				cancel()
+				if err != nil {
+					errs <- err
+					continue
+				}
+				l, err := aliceSession.Ledger()
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if _, err = l.GetTransactionReceipt(f.TxID()); err != nil {
+					errs <- err
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	for g := 0; g < goroutines; g++ {
+		for i := 0; i < iterations; i++ {
+			key := fmt.Sprintf("key-%d-%d", g, i)
+			validateValue(t, key, key, aliceSession)
+		}
+	}
+}
+
+func TestDataContext_BulkWriter(t *testing.T) {
+	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "server"})
+	testServer, _, _, err := SetupTestServerWithParams(t, clientCertTemDir, 100*time.Millisecond, 10)
+	defer testServer.Stop()
+	require.NoError(t, err)
+	_, _, aliceSession := startServerConnectOpenAdminCreateUserAndUserSession(t, testServer, clientCertTemDir, "alice")
+
+	w, err := aliceSession.BulkWriter(context.Background(), &BulkWriterOptions{
+		MaxTxOps:    10,
+		MaxInFlight: 3,
+	})
+	require.NoError(t, err)
+
+	results := make(chan []*BulkResult, 1)
+	go func() {
+		var collected []*BulkResult
+		for res := range w.Results() {
+			collected = append(collected, res)
+		}
+		results <- collected
+	}()
+
+	const keys = 50
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("bulk-key-%d", i)
+		require.NoError(t, w.Write(context.Background(), &BulkOp{DBName: "bdb", Key: key, Value: []byte(key)}))
+	}
+	// not existing database invalidates the transaction
+	require.NoError(t, w.Write(context.Background(), &BulkOp{DBName: "nodb", Key: "key", Value: []byte("value")}))
+	require.NoError(t, w.Close())
+
+	txIDs := map[string]bool{}
+	for _, res := range <-results {
+		if res.Op.DBName == "nodb" {
+			require.True(t, errors.Is(res.Err, ErrDBNotExist))
+			continue
+		}
+		require.NoError(t, res.Err)
+		require.NotNil(t, res.Receipt)
+		txIDs[res.TxID] = true
+	}
+	require.Len(t, txIDs, 5)
+
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("bulk-key-%d", i)
+		validateValue(t, key, key, aliceSession)
+	}
+}
+
 func putKeySync(t *testing.T, dbName, key string, value string, user string, session DBSession) {
 	tx, err := session.DataTx()
 	require.NoError(t, err)
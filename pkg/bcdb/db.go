@@ -5,18 +5,19 @@ package bcdb
 import (
 	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
-	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/wallet"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/crypto"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/cryptoservice"
@@ -27,32 +28,88 @@ import (
 )
 
 // BCDB Blockchain Database interface, defines set of APIs
-// required to operate with BCDB instance
+// required to operate with BCDB instance. BCDB is safe for concurrent use
 type BCDB interface {
 	// Session instantiates session to the database
 	Session(config *config.SessionConfig) (DBSession, error)
+	// SessionFromWallet instantiates session to the database with credentials of the user
+	// kept in the wallet, UserConfig of the session configuration is ignored
+	SessionFromWallet(w *wallet.Wallet, userID string, config *config.SessionConfig) (DBSession, error)
+	// Close closes all sessions opened by the instance and stops background work, i.e. nodes discovery
+	// and health checks of the replicas. New sessions are rejected with ErrSessionClosed
+	Close() error
 }
 
-// DBSession captures user's session
+// DBSession captures user's session.
+// Each method has a `Ctx` variant, which takes context.Context to control
+// cancellation and deadline of the requests sent to the server.
+// DBSession is safe for concurrent use by multiple goroutines, its contexts share
+// the connections to the replicas and the certificates of the cluster nodes
 type DBSession interface {
 	UsersTx() (UsersTxContext, error)
+	UsersTxCtx(ctx context.Context) (UsersTxContext, error)
 	DataTx() (DataTxContext, error)
+	DataTxCtx(ctx context.Context) (DataTxContext, error)
 	DBsTx() (DBsTxContext, error)
+	DBsTxCtx(ctx context.Context) (DBsTxContext, error)
 	ConfigTx() (ConfigTxContext, error)
+	ConfigTxCtx(ctx context.Context) (ConfigTxContext, error)
 	Provenance() (Provenance, error)
+	ProvenanceCtx(ctx context.Context) (Provenance, error)
 	Ledger() (Ledger, error)
+	LedgerCtx(ctx context.Context) (Ledger, error)
+	// LoadDataTx loads data transaction envelope, constructed and signed by other user,
+	// to co-sign and/or commit it
+	LoadDataTx(env *types.DataTxEnvelope) (LoadedDataTxContext, error)
+	LoadDataTxCtx(ctx context.Context, env *types.DataTxEnvelope) (LoadedDataTxContext, error)
+	// RunDataTx executes txFunc within fresh data transaction and commits it synchronously,
+	// txFunc should not commit or abort the transaction. If transaction invalidated due to
+	// MVCC conflict, i.e. key read by txFunc was changed before commit, txFunc re-executed
+	// within new transaction, according to opts. Returns ID and receipt of the last committed
+	// transaction, invalid transaction reported by *TxInvalidError
+	RunDataTx(ctx context.Context, txFunc func(tx DataTxContext) error, opts *RunDataTxOptions) (string, *types.TxReceipt, error)
+	// BulkWriter returns writer, which packs written operations into data transactions and submits them
+	// asynchronously, according to opts. Outcome of each operation is reported on BulkWriter.Results,
+	// submission of transactions is abandoned once ctx is done
+	BulkWriter(ctx context.Context, opts *BulkWriterOptions) (BulkWriter, error)
+	// Close releases connections of the session and stops receipt polling of its transaction futures.
+	// New contexts, queries and commits are rejected with ErrSessionClosed, in-flight commits are waited
+	// for up to 30 seconds. Subsequent calls are no-op
+	Close() error
+	// CloseCtx same as Close, in-flight commits are waited for until ctx is done
+	CloseCtx(ctx context.Context) error
 }
 
 var ErrTxSpent = errors.New("transaction committed or aborted")
 
 // TxContet an abstract API to capture general purpose
-// functionality for all types of transactions context
+// functionality for all types of transactions context.
+// Transaction context is safe for concurrent use, operations of the context are serialized,
+// e.g. Put called during Commit waits for the commit to complete and returns ErrTxSpent.
+// Use a context per transaction, contexts of the same session run concurrently
 type TxContext interface {
 	// Commit submits transaction to the server, can be sync or async.
 	// Sync option returns tx id and tx receipt and
 	// in case of error, commitTimeout error is one of possible errors to return.
-	// Async returns tx id, always nil as tx receipt or error
+	// If transaction was invalidated by the server, receipt returned along with *TxInvalidError.
+	// Async returns tx id, always nil as tx receipt or error.
+	// Failed commit spends the context, unless the transaction ID was set by SetTxID
 	Commit(sync bool) (string, *types.TxReceipt, error)
+	// CommitCtx same as Commit, the submission is abandoned once ctx is done
+	CommitCtx(ctx context.Context, sync bool) (string, *types.TxReceipt, error)
+	// CommitFuture submits transaction to the server and returns TxFuture to track transaction
+	// until its receipt is available. In case of server side timeout of sync commit or async commit,
+	// SDK polls transaction receipt in the background
+	CommitFuture(ctx context.Context, sync bool) (TxFuture, error)
+	// SetTxID assigns ID to the transaction instead of random one, should be called before commit.
+	// Commit of transaction with ID set by the caller returns the receipt of already committed
+	// transaction with this ID, if any. Server rejects transaction with ID of pending transaction,
+	// commit returns *ServerError, which wraps ErrDuplicateTxID, and CommitFuture polls the receipt
+	// in this case. Therefore transaction with ID derived from the logical operation, e.g. by
+	// ComputeDeterministicTxID, can be committed again within new context if the outcome of the
+	// previous commit isn't known. Context stays open if commit fails, Commit called again resubmits
+	// the same envelope, changes made to the context after the failed commit aren't included
+	SetTxID(txID string) error
 	// Abort cancel submission and abandon all changes
 	// within given transaction context
 	Abort() error
@@ -60,38 +117,68 @@ type TxContext interface {
 	TxEnvelope() (proto.Message, error)
 }
 
+// Ledger provides access to the ledger data, each method has a `Ctx`
+// variant, which takes context.Context to control the request.
+// Ledger is stateless and safe for concurrent use
 type Ledger interface {
 	// GetBlockHeader returns block header from ledger
 	GetBlockHeader(blockNum uint64) (*types.BlockHeader, error)
+	GetBlockHeaderCtx(ctx context.Context, blockNum uint64) (*types.BlockHeader, error)
 	// GetLedgerPath returns cryptographically verifiable path between any block pairs in ledger skip list
 	GetLedgerPath(startBlock, endBlock uint64) ([]*types.BlockHeader, error)
+	GetLedgerPathCtx(ctx context.Context, startBlock, endBlock uint64) ([]*types.BlockHeader, error)
 	// GetTransactionProof returns intermediate hashes from hash(tx, validating info) to root of
 	// tx merkle tree stored in block header
 	GetTransactionProof(blockNum uint64, txIndex int) (*TxProof, error)
+	GetTransactionProofCtx(ctx context.Context, blockNum uint64, txIndex int) (*TxProof, error)
 	// GetTransactionReceipt return block header where tx is stored and tx index inside block
 	GetTransactionReceipt(txId string) (*types.TxReceipt, error)
+	GetTransactionReceiptCtx(ctx context.Context, txId string) (*types.TxReceipt, error)
+	// VerifyTx verifies transaction evidence end to end: inclusion of the transaction into the tx merkle tree
+	// of the receipt block, presence of the receipt block in the ledger, verified by ledger path from the ledger
+	// checkpoint of the session, and the validation flag of the transaction. Failed checks are reported in
+	// TxVerificationReport, error returned if verification can't be completed. If the session doesn't verify
+	// the ledger, the ledger path can't be anchored and the report is marked Unanchored
+	VerifyTx(envelope proto.Message, receipt *types.TxReceipt) (*TxVerificationReport, error)
+	VerifyTxCtx(ctx context.Context, envelope proto.Message, receipt *types.TxReceipt) (*TxVerificationReport, error)
+	// VerifyTxFrom same as VerifyTx, the ledger path is verified from the trusted block header, e.g. obtained
+	// out of band, instead of the ledger checkpoint
+	VerifyTxFrom(trusted *types.BlockHeader, envelope proto.Message, receipt *types.TxReceipt) (*TxVerificationReport, error)
+	VerifyTxFromCtx(ctx context.Context, trusted *types.BlockHeader, envelope proto.Message, receipt *types.TxReceipt) (*TxVerificationReport, error)
 }
 
+// Provenance provides access to the provenance data, each method has a `Ctx`
+// variant, which takes context.Context to control the request.
+// Provenance is stateless and safe for concurrent use
 type Provenance interface {
 	// GetHistoricalData return all historical values for specific dn and key
 	// Value returned with its associated metadata, including block number, tx index, etc
 	GetHistoricalData(dbName, key string) ([]*types.ValueWithMetadata, error)
+	GetHistoricalDataCtx(ctx context.Context, dbName, key string) ([]*types.ValueWithMetadata, error)
 	// GetHistoricalDataAt returns value for specific version, if exist
 	GetHistoricalDataAt(dbName, key string, version *types.Version) (*types.ValueWithMetadata, error)
+	GetHistoricalDataAtCtx(ctx context.Context, dbName, key string, version *types.Version) (*types.ValueWithMetadata, error)
 	// GetPreviousHistoricalData returns value precedes given version, including its metadata, i.e version
 	GetPreviousHistoricalData(dbName, key string, version *types.Version) ([]*types.ValueWithMetadata, error)
+	GetPreviousHistoricalDataCtx(ctx context.Context, dbName, key string, version *types.Version) ([]*types.ValueWithMetadata, error)
 	// GetNextHistoricalData returns value succeeds given version, including its metadata
 	GetNextHistoricalData(dbName, key string, version *types.Version) ([]*types.ValueWithMetadata, error)
+	GetNextHistoricalDataCtx(ctx context.Context, dbName, key string, version *types.Version) ([]*types.ValueWithMetadata, error)
 	// GetDataReadByUser returns all user reads
 	GetDataReadByUser(userID string) ([]*types.KVWithMetadata, error)
+	GetDataReadByUserCtx(ctx context.Context, userID string) ([]*types.KVWithMetadata, error)
 	// GetDataWrittenByUser returns all user writes
 	GetDataWrittenByUser(userID string) ([]*types.KVWithMetadata, error)
+	GetDataWrittenByUserCtx(ctx context.Context, userID string) ([]*types.KVWithMetadata, error)
 	// GetReaders returns all users who read value associated with the key
 	GetReaders(dbName, key string) ([]string, error)
+	GetReadersCtx(ctx context.Context, dbName, key string) ([]string, error)
 	// GetWriters returns all users who wrote value associated with the key
 	GetWriters(dbName, key string) ([]string, error)
+	GetWritersCtx(ctx context.Context, dbName, key string) ([]string, error)
 	// GetTxIDsSubmittedByUser IDs of all tx submitted by user
 	GetTxIDsSubmittedByUser(userID string) ([]string, error)
+	GetTxIDsSubmittedByUserCtx(ctx context.Context, userID string) ([]string, error)
 }
 
 //go:generate mockery --dir . --name Signer --case underscore --output mocks/
@@ -119,27 +206,11 @@ func Create(config *config.ConnectionConfig) (BCDB, error) {
 		}
 	}
 
-	// Load root CA certificates
-	certsPool := x509.NewCertPool()
-	for _, rootCAPath := range config.RootCAs {
-		rootCABytes, err := ioutil.ReadFile(rootCAPath)
-		if err != nil {
-			dbLogger.Errorf("failed to read root CA certificate, due to %s", err)
-			return nil, errors.Wrap(err, "failed to read root CA certificate")
-		}
-		// TODO there are might be multiple PEM encoded blocks need to make
-		// sure we read correct one
-		pemBlock, _ := pem.Decode(rootCABytes)
-		if pemBlock == nil {
-			dbLogger.Error("failed decoding root CA certificate")
-			return nil, errors.New("failed decoding root CA certificate")
-		}
-		rootCACert, err := x509.ParseCertificate(pemBlock.Bytes)
-		if err != nil {
-			dbLogger.Errorf("failed to parse X509 root CA certificate, due to %s", err)
-			return nil, errors.Wrap(err, "failed to parse X509 root CA certificate")
-		}
-		certsPool.AddCert(rootCACert)
+	// Load root and intermediate CA certificates
+	caCerts, err := loadCACertificates(config.RootCAs)
+	if err != nil {
+		dbLogger.Errorf("failed to load CA certificates, due to %s", err)
+		return nil, err
 	}
 	// Validate replica set URIs
 	urls := map[string]*url.URL{}
@@ -152,71 +223,234 @@ func Create(config *config.ConnectionConfig) (BCDB, error) {
 		urls[uri.ID] = replicaURL
 	}
 
+	selector, err := newReplicaSelector(urls, config.ReplicaSelection, dbLogger)
+	if err != nil {
+		dbLogger.Errorf("failed to create replica selector, due to %s", err)
+		return nil, err
+	}
+
+	// Server TLS certificates are verified with the configured CA certificates, intermediate CAs are
+	// part of the root pool, therefore chains issued through them are verified as well
+	var rootCAs *x509.CertPool
+	if len(config.RootCAs) > 0 {
+		rootCAs = caCerts.roots
+	}
+	tlsConfig, err := newTLSConfig(config.TLS, rootCAs)
+	if err != nil {
+		dbLogger.Errorf("failed to create TLS configuration, due to %s", err)
+		return nil, err
+	}
+
+	var discovery *nodeDiscovery
+	if config.Discovery != nil {
+		discovery = newNodeDiscovery(urls, config.Discovery, selector, dbLogger)
+	}
+
 	return &bDB{
-		replicaSet: urls,
-		rootCAs:    certsPool,
-		logger:     dbLogger,
+		replicaSet:      urls,
+		replicaSelector: selector,
+		discovery:       discovery,
+		caCerts:         caCerts,
+		tlsConfig:       tlsConfig,
+		logger:          dbLogger,
 	}, nil
 }
 
 type bDB struct {
-	replicaSet map[string]*url.URL
-	rootCAs    *x509.CertPool
-	logger     *logger.SugarLogger
+	replicaSet      map[string]*url.URL
+	replicaSelector *replicaSelector
+	discovery       *nodeDiscovery
+	caCerts         *caCertPools
+	tlsConfig       *tls.Config
+	// open sessions, closed by Close
+	mu       sync.Mutex
+	sessions map[*dbSession]struct{}
+	closed   bool
+	logger   *logger.SugarLogger
 }
 
 // Session parses sessions configuration and opens session to BCDB, takes
 // care to read user
 func (b *bDB) Session(cfg *config.SessionConfig) (DBSession, error) {
-	signer, err := crypto.NewSigner(&crypto.SignerOptions{
-		KeyFilePath: cfg.UserConfig.PrivateKeyPath,
-	})
+	if cfg.UserConfig == nil {
+		b.logger.Error("user config is not provided")
+		return nil, errors.New("user config is not provided")
+	}
+
+	signer, err := userSigner(cfg.UserConfig)
 	if err != nil {
-		b.logger.Errorf("cannot create signer with user's private key, from %s, due to %s",
-			cfg.UserConfig.PrivateKeyPath, err)
+		b.logger.Errorf("cannot create signer with user's private key, due to %s", err)
 		return nil, errors.Wrap(err, "cannot create signer with user's private key")
 	}
 
-	certBytes, err := ioutil.ReadFile(cfg.UserConfig.CertPath)
+	certBytes, err := userCertificate(cfg.UserConfig)
 	if err != nil {
-		b.logger.Errorf("cannot read user's certificate with user's private key, from %s, due to %s",
-			cfg.UserConfig.CertPath, err)
+		b.logger.Errorf("cannot read user's certificate, due to %s", err)
 		return nil, errors.Wrap(err, "cannot read user's certificate with user's private key")
 	}
 
-	return &dbSession{
-		userID:       cfg.UserConfig.UserID,
-		signer:       signer,
-		userCert:     certBytes,
-		replicaSet:   b.replicaSet,
-		rootCAs:      b.rootCAs,
-		txTimeout:    cfg.TxTimeout,
-		queryTimeout: cfg.QueryTimeout,
-		logger:       b.logger,
-	}, nil
+	session := &dbSession{
+		userID:          cfg.UserConfig.UserID,
+		signer:          signer,
+		userCert:        certBytes,
+		replicaSet:      b.replicaSet,
+		replicaSelector: b.replicaSelector,
+		discovery:       b.discovery,
+		caCerts:         b.caCerts,
+		tlsConfig:       b.tlsConfig,
+		txTimeout:       cfg.TxTimeout,
+		queryTimeout:    cfg.QueryTimeout,
+		receiptPolling:  cfg.ReceiptPolling,
+		strictSnapshot:  cfg.StrictSnapshotReads,
+		lifecycle:       newSessionLifecycle(),
+		onClose:         b.removeSession,
+		logger:          b.logger,
+	}
+	// transport, and therefore connections to the replicas, is shared by all transaction contexts of the session
+	session.httpClient = session.newHTTPClient()
+	session.nodesCerts = newNodeCertsCache(cfg.NodeCertsCacheTTL, func(ctx context.Context) (map[string]*x509.Certificate, error) {
+		return session.getServerCertificates(ctx, session.httpClient)
+	})
+
+	if cfg.LedgerVerification != nil {
+		store := cfg.LedgerVerification.CheckpointStore
+		if store == nil {
+			if cfg.LedgerVerification.CheckpointPath == "" {
+				return nil, errors.New("ledger verification requires checkpoint path or checkpoint store")
+			}
+			store = NewFileCheckpointStore(cfg.LedgerVerification.CheckpointPath)
+		}
+		session.ledgerVerifier = newLedgerVerifier(func(ctx context.Context) (ledgerSource, error) {
+			// ledger is verified during in-flight commits, therefore it isn't bound to the session lifecycle
+			commonCtx, err := session.newUnboundTxContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return &ledger{commonCtx}, nil
+		}, store, b.logger)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, errors.WithMessage(ErrSessionClosed, "BCDB instance is closed")
+	}
+	if b.sessions == nil {
+		b.sessions = map[*dbSession]struct{}{}
+	}
+	b.sessions[session] = struct{}{}
+
+	if b.discovery != nil {
+		b.discovery.start(b.refreshMembership)
+	}
+
+	return session, nil
+}
+
+// Close closes all open sessions, stops nodes discovery and health checks of the replicas
+func (b *bDB) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	sessions := make([]*dbSession, 0, len(b.sessions))
+	for session := range b.sessions {
+		sessions = append(sessions, session)
+	}
+	b.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+
+	var closeErr error
+	for _, session := range sessions {
+		if err := session.CloseCtx(ctx); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	if b.discovery != nil {
+		b.discovery.stop()
+	}
+	if b.replicaSelector != nil {
+		b.replicaSelector.close()
+	}
+	return closeErr
+}
+
+func (b *bDB) removeSession(session *dbSession) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.sessions, session)
+}
+
+// refreshMembership refreshes the cluster membership with credentials of any open session
+func (b *bDB) refreshMembership() error {
+	b.mu.Lock()
+	var session *dbSession
+	for s := range b.sessions {
+		session = s
+		break
+	}
+	b.mu.Unlock()
+
+	if session == nil {
+		b.logger.Debug("no open session to refresh cluster membership with")
+		return nil
+	}
+	return session.refreshMembership()
+}
+
+func (b *bDB) SessionFromWallet(w *wallet.Wallet, userID string, cfg *config.SessionConfig) (DBSession, error) {
+	identity, err := w.Export(userID)
+	if err != nil {
+		b.logger.Errorf("cannot load identity %s from the wallet, due to %s", userID, err)
+		return nil, errors.WithMessagef(err, "cannot load identity %s from the wallet", userID)
+	}
+
+	walletCfg := &config.SessionConfig{}
+	if cfg != nil {
+		*walletCfg = *cfg
+	}
+	walletCfg.UserConfig = &config.UserConfig{
+		UserID:     identity.UserID,
+		Cert:       identity.Cert,
+		PrivateKey: identity.PrivateKey,
+	}
+	return b.Session(walletCfg)
 }
 
 type dbSession struct {
-	userID       string
-	signer       Signer
-	userCert     []byte
-	replicaSet   map[string]*url.URL
-	rootCAs      *x509.CertPool
-	txTimeout    time.Duration
-	queryTimeout time.Duration
-	logger       *logger.SugarLogger
+	userID          string
+	signer          Signer
+	userCert        []byte
+	replicaSet      map[string]*url.URL
+	replicaSelector *replicaSelector
+	discovery       *nodeDiscovery
+	caCerts         *caCertPools
+	tlsConfig       *tls.Config
+	httpClient      *http.Client
+	nodesCerts      *nodeCertsCache
+	txTimeout       time.Duration
+	queryTimeout    time.Duration
+	receiptPolling  *config.ReceiptPollingConfig
+	strictSnapshot  bool
+	ledgerVerifier  *ledgerVerifier
+	lifecycle       *sessionLifecycle
+	// onClose called once the session is closed
+	onClose func(session *dbSession)
+	logger  *logger.SugarLogger
 }
 
-func (d *dbSession) getNodesCerts(replica *url.URL, httpClient *http.Client) (map[string]*x509.Certificate, error) {
+// getClusterConfig downloads cluster configuration from the replica, returns the configuration
+// and the certificates of the nodes, verified with CA certificates
+func (d *dbSession) getClusterConfig(ctx context.Context, replica *url.URL, httpClient *http.Client) (*types.ClusterConfig, map[string]*x509.Certificate, error) {
 	nodesCerts := map[string]*x509.Certificate{}
 	getConfig := &url.URL{
 		Path: constants.URLForGetConfig(),
 	}
 	configREST := replica.ResolveReference(getConfig)
-	ctx := context.TODO()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, configREST.String(), nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	signature, err := cryptoservice.SignQuery(d.signer, &types.GetConfigQuery{
@@ -224,7 +458,7 @@ func (d *dbSession) getNodesCerts(replica *url.URL, httpClient *http.Client) (ma
 	})
 	if err != nil {
 		d.logger.Errorf("failed signed transaction, %s", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	req.Header.Set("Accept", "application/json")
@@ -233,60 +467,68 @@ func (d *dbSession) getNodesCerts(replica *url.URL, httpClient *http.Client) (ma
 	response, err := httpClient.Do(req)
 	if err != nil {
 		d.logger.Errorf("failed to send transaction to server %s, due to %s", getConfig.String(), err)
-		return nil, err
+		return nil, nil, err
 	}
+	defer closeResponseBody(response)
 
 	if response.StatusCode != http.StatusOK {
 		d.logger.Errorf("error response from the server, %s", response.Status)
-		return nil, errors.New(fmt.Sprintf("error response from the server, %s", response.Status))
+		return nil, nil, errors.New(fmt.Sprintf("error response from the server, %s", response.Status))
 	}
 
 	resEnv := &types.ResponseEnvelope{}
 	err = json.NewDecoder(response.Body).Decode(resEnv)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	payload := &types.Payload{}
 	err = json.Unmarshal(resEnv.GetPayload(), payload)
 	if err != nil {
 		d.logger.Errorf("failed to unmarshal response payload, due to %s", err)
-		return nil, err
+		return nil, nil, err
 	}
 
-	// TODO need to validate payload's signature
-	// resEnv.Signature - the signature over payload
-	// payload.GetHeader().NodeID - the id of the node signed response
-
 	configResponse := &types.GetConfigResponse{}
 	err = json.Unmarshal(payload.GetResponse(), configResponse)
 	if err != nil {
 		d.logger.Errorf("failed to unmarshal config response, due to %s", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, node := range configResponse.GetConfig().GetNodes() {
 		cert, err := x509.ParseCertificate(node.Certificate)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		_, err = cert.Verify(x509.VerifyOptions{
-			Roots: d.rootCAs,
-		})
+		_, err = cert.Verify(d.caCerts.verifyOptions())
 		if err != nil {
-			return nil, err
+			d.logger.Errorf("failed to verify certificate of node %s, due to %s", node.ID, err)
+			return nil, nil, errors.Wrapf(err, "failed to verify certificate of node %s", node.ID)
 		}
 
 		nodesCerts[node.ID] = cert
 	}
 
-	return nodesCerts, nil
+	// Config response carries the certificates of all cluster nodes, therefore signature
+	// is validated against the node certificates, which were already verified with root CAs
+	if err = verifyResponseSignature(nodesCerts, resEnv, payload); err != nil {
+		d.logger.Errorf("failed to verify config response, due to %s", err)
+		return nil, nil, err
+	}
+
+	return configResponse.GetConfig(), nodesCerts, nil
 }
 
 // UsersTx returns user's transaction context
 func (d *dbSession) UsersTx() (UsersTxContext, error) {
-	commonCtx, err := d.newCommonTxContext()
+	return d.UsersTxCtx(context.Background())
+}
+
+// UsersTxCtx same as UsersTx, ctx controls requests sent to the server during context creation
+func (d *dbSession) UsersTxCtx(ctx context.Context) (UsersTxContext, error) {
+	commonCtx, err := d.newCommonTxContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -298,7 +540,12 @@ func (d *dbSession) UsersTx() (UsersTxContext, error) {
 
 // DBsTx returns database management transaction context
 func (d *dbSession) DBsTx() (DBsTxContext, error) {
-	commonCtx, err := d.newCommonTxContext()
+	return d.DBsTxCtx(context.Background())
+}
+
+// DBsTxCtx same as DBsTx, ctx controls requests sent to the server during context creation
+func (d *dbSession) DBsTxCtx(ctx context.Context) (DBsTxContext, error) {
+	commonCtx, err := d.newCommonTxContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -312,20 +559,58 @@ func (d *dbSession) DBsTx() (DBsTxContext, error) {
 
 // DataTx returns data's transaction context
 func (d *dbSession) DataTx() (DataTxContext, error) {
-	commonCtx, err := d.newCommonTxContext()
+	return d.DataTxCtx(context.Background())
+}
+
+// DataTxCtx same as DataTx, ctx controls requests sent to the server during context creation
+func (d *dbSession) DataTxCtx(ctx context.Context) (DataTxContext, error) {
+	commonCtx, err := d.newCommonTxContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	dataTx := &dataTxContext{
 		commonTxContext: commonCtx,
 		operations:      make(map[string]*dbOperations),
+		strictSnapshot:  d.strictSnapshot,
 	}
 	return dataTx, nil
 }
 
+// RunDataTx executes txFunc within data transaction, retrying on MVCC conflict
+func (d *dbSession) RunDataTx(ctx context.Context, txFunc func(tx DataTxContext) error, opts *RunDataTxOptions) (string, *types.TxReceipt, error) {
+	return runDataTx(ctx, d.DataTxCtx, txFunc, opts)
+}
+
+// BulkWriter returns writer of data transactions
+func (d *dbSession) BulkWriter(ctx context.Context, opts *BulkWriterOptions) (BulkWriter, error) {
+	if err := d.lifecycle.check(); err != nil {
+		return nil, err
+	}
+	return newBulkWriter(ctx, d.DataTxCtx, opts), nil
+}
+
+// LoadDataTx returns data transaction context of loaded transaction envelope
+func (d *dbSession) LoadDataTx(env *types.DataTxEnvelope) (LoadedDataTxContext, error) {
+	return d.LoadDataTxCtx(context.Background(), env)
+}
+
+// LoadDataTxCtx same as LoadDataTx, ctx controls requests sent to the server during context creation
+func (d *dbSession) LoadDataTxCtx(ctx context.Context, env *types.DataTxEnvelope) (LoadedDataTxContext, error) {
+	commonCtx, err := d.newCommonTxContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newLoadedDataTxContext(commonCtx, env)
+}
+
 // ConfigTx returns config transaction context
 func (d *dbSession) ConfigTx() (ConfigTxContext, error) {
-	commonCtx, err := d.newCommonTxContext()
+	return d.ConfigTxCtx(context.Background())
+}
+
+// ConfigTxCtx same as ConfigTx, ctx controls requests sent to the server during context creation
+func (d *dbSession) ConfigTxCtx(ctx context.Context) (ConfigTxContext, error) {
+	commonCtx, err := d.newCommonTxContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -336,7 +621,7 @@ func (d *dbSession) ConfigTx() (ConfigTxContext, error) {
 		newConfig:            nil,
 	}
 
-	if err = configTx.queryClusterConfig(); err != nil {
+	if err = configTx.queryClusterConfig(ctx); err != nil {
 		return nil, err
 	}
 
@@ -345,7 +630,12 @@ func (d *dbSession) ConfigTx() (ConfigTxContext, error) {
 
 // Provenance returns handler to access provenance
 func (d *dbSession) Provenance() (Provenance, error) {
-	commonCtx, err := d.newCommonTxContext()
+	return d.ProvenanceCtx(context.Background())
+}
+
+// ProvenanceCtx same as Provenance, ctx controls requests sent to the server during context creation
+func (d *dbSession) ProvenanceCtx(ctx context.Context) (Provenance, error) {
+	commonCtx, err := d.newCommonTxContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -356,7 +646,12 @@ func (d *dbSession) Provenance() (Provenance, error) {
 
 // Ledger returns handler to access bcdb ledger data
 func (d *dbSession) Ledger() (Ledger, error) {
-	commonCtx, err := d.newCommonTxContext()
+	return d.LedgerCtx(context.Background())
+}
+
+// LedgerCtx same as Ledger, ctx controls requests sent to the server during context creation
+func (d *dbSession) LedgerCtx(ctx context.Context) (Ledger, error) {
+	commonCtx, err := d.newCommonTxContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -365,46 +660,132 @@ func (d *dbSession) Ledger() (Ledger, error) {
 	}, nil
 }
 
-func (d *dbSession) newCommonTxContext() (*commonTxContext, error) {
-	httpClient := d.newHTTPClient()
+// Close closes the session, waits for in-flight commits for up to 30 seconds
+func (d *dbSession) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+	return d.CloseCtx(ctx)
+}
+
+// CloseCtx closes the session, waits for in-flight commits until ctx is done
+func (d *dbSession) CloseCtx(ctx context.Context) error {
+	closed, err := d.lifecycle.close(ctx)
+	if !closed {
+		return nil
+	}
+	d.httpClient.CloseIdleConnections()
+	if d.onClose != nil {
+		d.onClose(d)
+	}
+	if err != nil {
+		d.logger.Errorf("failed to close session of user %s gracefully, due to %s", d.userID, err)
+		return err
+	}
+	return nil
+}
+
+func (d *dbSession) newCommonTxContext(ctx context.Context) (*commonTxContext, error) {
+	if err := d.lifecycle.check(); err != nil {
+		return nil, err
+	}
+	commonCtx, err := d.newUnboundTxContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	commonCtx.lifecycle = d.lifecycle
+	return commonCtx, nil
+}
 
-	nodesCerts, err := d.getServerCertificates(httpClient)
+// newUnboundTxContext returns context, which isn't bound to the session lifecycle, i.e. can be used after
+// the session is closed
+func (d *dbSession) newUnboundTxContext(ctx context.Context) (*commonTxContext, error) {
+	nodesCerts, err := d.nodesCerts.get(ctx)
 	if err != nil {
 		return nil, err
 	}
 	commonTxContext := &commonTxContext{
-		userID:        d.userID,
-		signer:        d.signer,
-		userCert:      d.userCert,
-		replicaSet:    d.replicaSet,
-		nodesCerts:    nodesCerts,
-		restClient:    NewRestClient(d.userID, httpClient, d.signer),
-		commitTimeout: d.txTimeout,
-		queryTimeout:  d.queryTimeout,
-		logger:        d.logger,
+		userID:          d.userID,
+		signer:          d.signer,
+		userCert:        d.userCert,
+		replicaSet:      d.replicaSet,
+		replicaSelector: d.replicaSelector,
+		discovery:       d.discovery,
+		nodesCerts:      nodesCerts,
+		nodesCertsCache: d.nodesCerts,
+		restClient:      NewRestClient(d.userID, d.httpClient, d.signer),
+		commitTimeout:   d.txTimeout,
+		queryTimeout:    d.queryTimeout,
+		receiptPolling:  d.receiptPolling,
+		ledgerVerifier:  d.ledgerVerifier,
+		logger:          d.logger,
 	}
 	return commonTxContext, nil
 }
 
-func (d *dbSession) getServerCertificates(httpClient *http.Client) (map[string]*x509.Certificate, error) {
+func (d *dbSession) getServerCertificates(ctx context.Context, httpClient *http.Client) (map[string]*x509.Certificate, error) {
+	replicaSet := d.replicaSet
+	if d.replicaSelector != nil {
+		replicaSet = d.replicaSelector.replicaSet()
+	}
+
+	var clusterConfig *types.ClusterConfig
 	var nodesCerts map[string]*x509.Certificate
-	var err error
-	for _, replica := range d.replicaSet {
-		nodesCerts, err = d.getNodesCerts(replica, httpClient)
+	for _, replica := range replicaSet {
+		cfg, certs, err := d.getClusterConfig(ctx, replica, httpClient)
 		if err != nil {
 			d.logger.Errorf("failed to obtain server's certificate, replica: %s", replica)
 			continue
 		}
+		clusterConfig, nodesCerts = cfg, certs
+		break
+	}
+
+	if len(nodesCerts) == 0 && d.discovery != nil {
+		// discovered replicas are unreachable, fall back to the bootstrap endpoints
+		for id, replica := range d.discovery.bootstrap {
+			if current, ok := replicaSet[id]; ok && current.String() == replica.String() {
+				continue
+			}
+			cfg, certs, err := d.getClusterConfig(ctx, replica, httpClient)
+			if err != nil {
+				d.logger.Errorf("failed to obtain server's certificate, bootstrap replica: %s", replica)
+				continue
+			}
+			clusterConfig, nodesCerts = cfg, certs
+			break
+		}
 	}
 
 	if len(nodesCerts) == 0 {
-		d.logger.Errorf("failed to obtain server's certificate, replicaSet: %s", d.replicaSet)
+		d.logger.Errorf("failed to obtain server's certificate, replicaSet: %s", replicaSet)
 		return nil, errors.New("failed to obtain server's certificate")
 	}
+	if d.discovery != nil {
+		d.discovery.update(clusterConfig.GetNodes())
+	}
 	return nodesCerts, nil
 }
 
+// refreshMembership downloads cluster configuration and updates the replica set with the cluster nodes
+func (d *dbSession) refreshMembership() error {
+	if err := d.lifecycle.check(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if d.queryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.queryTimeout)
+		defer cancel()
+	}
+	_, err := d.nodesCerts.refresh(ctx)
+	return err
+}
+
 func (d *dbSession) newHTTPClient() *http.Client {
+	var tlsConfig *tls.Config
+	if d.tlsConfig != nil {
+		tlsConfig = d.tlsConfig.Clone()
+	}
 	httpClient := &http.Client{
 		Transport: &http.Transport{
 			Proxy: http.ProxyFromEnvironment,
@@ -417,12 +798,28 @@ func (d *dbSession) newHTTPClient() *http.Client {
 			MaxIdleConnsPerHost:   100,
 			IdleConnTimeout:       90 * time.Second,
 			TLSHandshakeTimeout:   10 * time.Second,
+			TLSClientConfig:       tlsConfig,
 			ExpectContinueTimeout: 1 * time.Second,
 		},
 	}
 	return httpClient
 }
 
+// ComputeDeterministicTxID returns transaction ID derived from the user certificate and idempotency key,
+// the same key of the same user always results in the same transaction ID
+func ComputeDeterministicTxID(userCert []byte, idempotencyKey []byte) (string, error) {
+	if len(idempotencyKey) == 0 {
+		return "", errors.New("idempotency key should not be empty")
+	}
+	b := append(append([]byte{}, idempotencyKey...), userCert...)
+
+	sha256Hash, err := crypto.ComputeSHA256Hash(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(sha256Hash), nil
+}
+
 func ComputeTxID(userCert []byte) (string, error) {
 	nonce := make([]byte, 24)
 	_, err := rand.Read(nonce)
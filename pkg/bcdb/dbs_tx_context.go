@@ -3,6 +3,8 @@
 package bcdb
 
 import (
+	"context"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/cryptoservice"
@@ -18,6 +20,8 @@ type DBsTxContext interface {
 	DeleteDB(dbName string) error
 	// Exists checks whenever database is already created
 	Exists(dbName string) (bool, error)
+	// ExistsCtx same as Exists, ctx controls the request sent to the server
+	ExistsCtx(ctx context.Context, dbName string) (bool, error)
 }
 
 type dbsTxContext struct {
@@ -27,14 +31,34 @@ type dbsTxContext struct {
 }
 
 func (d *dbsTxContext) Commit(sync bool) (string, *types.TxReceipt, error) {
-	return d.commit(d, constants.PostDBTx, sync)
+	return d.CommitCtx(context.Background(), sync)
+}
+
+func (d *dbsTxContext) CommitCtx(ctx context.Context, sync bool) (string, *types.TxReceipt, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.commit(ctx, d, constants.PostDBTx, sync)
+}
+
+func (d *dbsTxContext) CommitFuture(ctx context.Context, sync bool) (TxFuture, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.commitFuture(ctx, d, constants.PostDBTx, sync)
 }
 
 func (d *dbsTxContext) Abort() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	return d.commonTxContext.abort(d)
 }
 
 func (d *dbsTxContext) CreateDB(dbName string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if d.txSpent {
 		return ErrTxSpent
 	}
@@ -44,6 +68,9 @@ func (d *dbsTxContext) CreateDB(dbName string) error {
 }
 
 func (d *dbsTxContext) DeleteDB(dbName string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if d.txSpent {
 		return ErrTxSpent
 	}
@@ -53,13 +80,20 @@ func (d *dbsTxContext) DeleteDB(dbName string) error {
 }
 
 func (d *dbsTxContext) Exists(dbName string) (bool, error) {
+	return d.ExistsCtx(context.Background(), dbName)
+}
+
+func (d *dbsTxContext) ExistsCtx(ctx context.Context, dbName string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if d.txSpent {
 		return false, ErrTxSpent
 	}
 
 	path := constants.URLForGetDBStatus(dbName)
 	res := &types.GetDBStatusResponse{}
-	err := d.handleRequest(path, &types.GetDBStatusQuery{
+	err := d.handleRequest(ctx, path, &types.GetDBStatusQuery{
 		UserID: d.userID,
 		DBName: dbName,
 	}, res)
@@ -0,0 +1,165 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/logger"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+)
+
+const defaultDiscoveryRefreshInterval = time.Minute
+
+// nodeDiscovery keeps the replica set in sync with the nodes of the cluster configuration
+type nodeDiscovery struct {
+	// bootstrap replicas from the connection configuration, by ID
+	bootstrap map[string]*url.URL
+	// scheme of discovered replicas endpoints, the scheme of bootstrap endpoints
+	scheme          string
+	refreshInterval time.Duration
+	selector        *replicaSelector
+	// refreshNow requests immediate refresh of the membership
+	refreshNow chan struct{}
+	done       chan struct{}
+	startOnce  sync.Once
+	stopOnce   sync.Once
+	logger     *logger.SugarLogger
+}
+
+func newNodeDiscovery(bootstrap map[string]*url.URL, cfg *config.DiscoveryConfig, selector *replicaSelector, logger *logger.SugarLogger) *nodeDiscovery {
+	d := &nodeDiscovery{
+		bootstrap:       bootstrap,
+		scheme:          "http",
+		refreshInterval: cfg.RefreshInterval,
+		selector:        selector,
+		refreshNow:      make(chan struct{}, 1),
+		done:            make(chan struct{}),
+		logger:          logger,
+	}
+	if d.refreshInterval <= 0 {
+		d.refreshInterval = defaultDiscoveryRefreshInterval
+	}
+
+	var ids []string
+	for id := range bootstrap {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if len(ids) > 0 {
+		d.scheme = bootstrap[ids[0]].Scheme
+	}
+	return d
+}
+
+// start starts periodic refresh of the membership, refresh downloads cluster configuration and
+// updates the replica set. Refresh requires user's credentials, therefore it is started by the first
+// session, subsequent calls are ignored
+func (d *nodeDiscovery) start(refresh func() error) {
+	d.startOnce.Do(func() {
+		go d.run(refresh)
+	})
+}
+
+func (d *nodeDiscovery) run(refresh func() error) {
+	ticker := time.NewTicker(d.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-d.refreshNow:
+		case <-d.done:
+			return
+		}
+		if err := refresh(); err != nil {
+			d.logger.Warnf("failed to refresh cluster membership, due to %s", err)
+		}
+	}
+}
+
+// stop stops periodic refresh of the membership
+func (d *nodeDiscovery) stop() {
+	d.stopOnce.Do(func() {
+		close(d.done)
+	})
+}
+
+// trigger requests immediate refresh of the membership, e.g. after config transaction changed cluster nodes
+func (d *nodeDiscovery) trigger() {
+	select {
+	case d.refreshNow <- struct{}{}:
+	default:
+	}
+}
+
+// update replaces the replica set with the nodes of the cluster configuration
+func (d *nodeDiscovery) update(nodes []*types.NodeConfig) {
+	replicaSet := map[string]*url.URL{}
+	for _, node := range nodes {
+		nodeURL := d.nodeURL(node)
+		if nodeURL == nil {
+			d.logger.Warnf("node %s has no routable address, address: %s, port: %d, node is skipped", node.ID, node.Address, node.Port)
+			continue
+		}
+		replicaSet[node.ID] = nodeURL
+	}
+	if len(replicaSet) == 0 {
+		d.logger.Warn("cluster configuration has no reachable nodes, replica set is kept")
+		return
+	}
+
+	current := d.selector.replicaSet()
+	for id, nodeURL := range replicaSet {
+		if currentURL, ok := current[id]; !ok || currentURL.String() != nodeURL.String() {
+			d.logger.Infof("discovered replica %s, endpoint: %s", id, nodeURL)
+		}
+	}
+	for id := range current {
+		if _, ok := replicaSet[id]; !ok {
+			d.logger.Infof("replica %s is not part of the cluster anymore", id)
+		}
+	}
+	d.selector.update(replicaSet)
+}
+
+// nodeURL returns endpoint of the node, nil if node has no routable address
+func (d *nodeDiscovery) nodeURL(node *types.NodeConfig) *url.URL {
+	if bootstrapURL, ok := d.bootstrap[node.ID]; ok {
+		return bootstrapURL
+	}
+	if node.Address == "" || node.Port == 0 {
+		return nil
+	}
+	if ip := net.ParseIP(node.Address); ip != nil && ip.IsUnspecified() {
+		return nil
+	}
+	return &url.URL{
+		Scheme: d.scheme,
+		Host:   net.JoinHostPort(node.Address, strconv.Itoa(int(node.Port))),
+	}
+}
+
+// nodesChanged checks whenever the set of nodes or their endpoints differ
+func nodesChanged(oldNodes, newNodes []*types.NodeConfig) bool {
+	if len(oldNodes) != len(newNodes) {
+		return true
+	}
+	endpoints := map[string]string{}
+	for _, node := range oldNodes {
+		endpoints[node.ID] = net.JoinHostPort(node.Address, strconv.Itoa(int(node.Port)))
+	}
+	for _, node := range newNodes {
+		endpoint, ok := endpoints[node.ID]
+		if !ok || endpoint != net.JoinHostPort(node.Address, strconv.Itoa(int(node.Port))) {
+			return true
+		}
+	}
+	return false
+}
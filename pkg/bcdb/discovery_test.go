@@ -0,0 +1,193 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeDiscovery_Update(t *testing.T) {
+	bootstrap := map[string]*url.URL{
+		"bootstrap": {Scheme: "https", Host: "bcdb.example:443"},
+		"node3":     {Scheme: "https", Host: "node3.bcdb.example:443"},
+	}
+	selector, err := newReplicaSelector(bootstrap, nil, createTestLogger(t))
+	require.NoError(t, err)
+	d := newNodeDiscovery(bootstrap, &config.DiscoveryConfig{}, selector, createTestLogger(t))
+	require.Equal(t, defaultDiscoveryRefreshInterval, d.refreshInterval)
+
+	d.update([]*types.NodeConfig{
+		{ID: "node1", Address: "10.0.0.1", Port: 6001},
+		{ID: "node2", Address: "fe80::2", Port: 6001},
+		{ID: "node3", Address: "10.0.0.3", Port: 6001},
+		{ID: "node4", Address: "0.0.0.0", Port: 6001},
+		{ID: "node5", Address: "node5.bcdb.example"},
+	})
+	require.Equal(t, map[string]*url.URL{
+		"node1": {Scheme: "https", Host: "10.0.0.1:6001"},
+		"node2": {Scheme: "https", Host: "[fe80::2]:6001"},
+		"node3": {Scheme: "https", Host: "node3.bcdb.example:443"},
+	}, selector.replicaSet())
+
+	// no reachable nodes, replica set is kept
+	d.update([]*types.NodeConfig{
+		{ID: "node4", Address: "::", Port: 6001},
+	})
+	require.Len(t, selector.replicaSet(), 3)
+}
+
+func TestNodesChanged(t *testing.T) {
+	nodes := []*types.NodeConfig{
+		{ID: "node1", Address: "10.0.0.1", Port: 6001},
+		{ID: "node2", Address: "10.0.0.2", Port: 6001},
+	}
+	require.False(t, nodesChanged(nodes, []*types.NodeConfig{nodes[1], nodes[0]}))
+	require.True(t, nodesChanged(nodes, nodes[:1]))
+	require.True(t, nodesChanged(nodes, []*types.NodeConfig{nodes[0], {ID: "node3", Address: "10.0.0.2", Port: 6001}}))
+	require.True(t, nodesChanged(nodes, []*types.NodeConfig{nodes[0], {ID: "node2", Address: "10.0.0.2", Port: 7001}}))
+
+	discovery := &nodeDiscovery{refreshNow: make(chan struct{}, 1)}
+	configTx := &configTxContext{
+		commonTxContext: &commonTxContext{discovery: discovery},
+		oldConfig:       &types.ClusterConfig{Nodes: nodes},
+	}
+	require.False(t, configTx.membershipChanged())
+	configTx.newConfig = &types.ClusterConfig{Nodes: nodes[:1]}
+	require.True(t, configTx.membershipChanged())
+
+	discovery.trigger()
+	discovery.trigger()
+	require.Len(t, discovery.refreshNow, 1)
+}
+
+func TestNodeDiscovery_Run(t *testing.T) {
+	d := &nodeDiscovery{
+		refreshInterval: 10 * time.Millisecond,
+		refreshNow:      make(chan struct{}, 1),
+		done:            make(chan struct{}),
+		logger:          createTestLogger(t),
+	}
+	var refreshes int32
+	d.start(func() error {
+		atomic.AddInt32(&refreshes, 1)
+		return nil
+	})
+	d.start(func() error {
+		panic("second refresher should not run")
+	})
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&refreshes) >= 3 }, time.Second, 5*time.Millisecond)
+
+	d.stop()
+	d.stop()
+	time.Sleep(20 * time.Millisecond)
+	stoppedAt := atomic.LoadInt32(&refreshes)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, stoppedAt, atomic.LoadInt32(&refreshes))
+}
+
+func TestSession_NodeDiscovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "CA")
+	caPath := writeTestPEM(t, dir, "CA.pem", certPEMBlock(ca.cert))
+	issueNode := func(id string) *testNode {
+		cert, key := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: id}})
+		return &testNode{id: id, cert: cert, key: key}
+	}
+	node1 := issueNode("node1")
+	node2 := issueNode("node2")
+	node3 := issueNode("node3")
+
+	// node2 listens on the port nobody listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	node2Port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	var mu sync.Mutex
+	var nodes []*types.NodeConfig
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != constants.URLForGetConfig() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		res := &types.GetConfigResponse{Config: &types.ClusterConfig{Nodes: nodes}}
+		mu.Unlock()
+		resEnv := &types.ResponseEnvelope{
+			Payload: MarshalOrPanic(&types.Payload{
+				Header:   &types.ResponseHeader{NodeID: node1.id},
+				Response: MarshalOrPanic(res),
+			}),
+		}
+		resEnv.Signature = node1.sign(resEnv.Payload)
+		require.NoError(t, json.NewEncoder(w).Encode(resEnv))
+	}))
+	defer server.Close()
+	serverPort := server.Listener.Addr().(*net.TCPAddr).Port
+	nodes = []*types.NodeConfig{
+		{ID: "node1", Address: "127.0.0.1", Port: uint32(serverPort), Certificate: node1.cert.Raw},
+		{ID: "node2", Address: "127.0.0.1", Port: uint32(node2Port), Certificate: node2.cert.Raw},
+	}
+
+	db, err := Create(&config.ConnectionConfig{
+		ReplicaSet: []*config.Replica{
+			{ID: "bootstrap", Endpoint: server.URL},
+		},
+		RootCAs:   []string{caPath},
+		Discovery: &config.DiscoveryConfig{RefreshInterval: time.Hour},
+		Logger:    createTestLogger(t),
+	})
+	require.NoError(t, err)
+	discovery := db.(*bDB).discovery
+	defer db.Close()
+
+	userCert, userKey := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}})
+	session, err := db.Session(&config.SessionConfig{
+		UserConfig: &config.UserConfig{
+			UserID:     "alice",
+			Cert:       pem.EncodeToMemory(certPEMBlock(userCert)),
+			PrivateKey: pem.EncodeToMemory(keyPEMBlock(t, userKey)),
+		},
+		QueryTimeout: time.Second,
+	})
+	require.NoError(t, err)
+
+	_, err = session.DataTx()
+	require.NoError(t, err)
+	require.Equal(t, map[string]*url.URL{
+		"node1": {Scheme: "http", Host: "127.0.0.1:" + strconv.Itoa(serverPort)},
+		"node2": {Scheme: "http", Host: "127.0.0.1:" + strconv.Itoa(node2Port)},
+	}, db.(*bDB).replicaSelector.replicaSet())
+
+	// node added by config transaction reaches the client after refresh
+	mu.Lock()
+	nodes = append(nodes, &types.NodeConfig{ID: "node3", Address: "127.0.0.1", Port: 7003, Certificate: node3.cert.Raw})
+	mu.Unlock()
+	discovery.trigger()
+	require.Eventually(t, func() bool {
+		_, ok := db.(*bDB).replicaSelector.replicaSet()["node3"]
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,127 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Errors to examine with errors.Is, the returned errors, e.g. *ServerError or *TxInvalidError,
+// carry the details of the failure
+var (
+	// ErrNotFound returned when requested entity, e.g. block or transaction receipt, not found by the server
+	ErrNotFound = errors.New("not found")
+	// ErrPermissionDenied returned when the user has no permission to execute query or transaction
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrMVCCConflict returned when transaction invalidated since keys it read were changed before commit
+	ErrMVCCConflict = errors.New("mvcc conflict")
+	// ErrDBNotExist returned when transaction invalidated since it accesses database which doesn't exist
+	ErrDBNotExist = errors.New("database does not exist")
+	// ErrIncorrectPassphrase returned when user's encrypted private key can't be decrypted with provided passphrase
+	ErrIncorrectPassphrase = errors.New("incorrect private key passphrase")
+	// ErrMalformedPrivateKey returned when user's private key is neither valid PEM encoded private key nor valid encrypted private key
+	ErrMalformedPrivateKey = errors.New("malformed private key")
+	// ErrSessionClosed returned by the session, its contexts and transaction futures once the session is closed
+	ErrSessionClosed = errors.New("session is closed")
+	// ErrDuplicateTxID returned when the server rejects transaction since transaction with the same ID
+	// is pending or already committed
+	ErrDuplicateTxID = errors.New("duplicate transaction ID")
+)
+
+// duplicateTxIDMessage part of the error message the server rejects transaction with duplicate ID with
+const duplicateTxIDMessage = "duplicate txID"
+
+// ServerError returned when the server responded with error status to query or transaction submission
+type ServerError struct {
+	// StatusCode HTTP status code returned by the server
+	StatusCode int
+	// Status HTTP status returned by the server
+	Status string
+	// Message error message returned by the server
+	Message string
+	// ReplicaID the ID of the replica which returned the error
+	ReplicaID string
+	// TxID the ID of submitted transaction, empty for queries
+	TxID string
+}
+
+func (e *ServerError) Error() string {
+	if e.TxID != "" {
+		return fmt.Sprintf("failed to submit transaction, server returned: status: %s, message: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("error handling request, server returned: status: %s, message: %s", e.Status, e.Message)
+}
+
+// Unwrap maps HTTP status code to one of the exported errors, if possible
+func (e *ServerError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrPermissionDenied
+	case http.StatusBadRequest:
+		if e.TxID != "" && strings.Contains(e.Message, duplicateTxIDMessage) {
+			return ErrDuplicateTxID
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// TxInvalidError returned when transaction was included into the block,
+// but marked invalid by the server during validation
+type TxInvalidError struct {
+	TxID   string
+	Flag   types.Flag
+	Reason string
+	// ReplicaID the ID of the replica which returned the receipt, empty if the receipt
+	// was obtained by polling
+	ReplicaID string
+}
+
+func (e *TxInvalidError) Error() string {
+	return fmt.Sprintf("transaction [%s] is invalid, flag: %s, reason: %s", e.TxID, e.Flag, e.Reason)
+}
+
+// Unwrap maps validation flag to one of the exported errors, if possible
+func (e *TxInvalidError) Unwrap() error {
+	switch e.Flag {
+	case types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE, types.Flag_INVALID_MVCC_CONFLICT_WITHIN_BLOCK:
+		return ErrMVCCConflict
+	case types.Flag_INVALID_DB_NOT_EXIST:
+		return ErrDBNotExist
+	case types.Flag_INVALID_NO_PERMISSION, types.Flag_INVALID_UNAUTHORISED:
+		return ErrPermissionDenied
+	default:
+		return nil
+	}
+}
+
+// validateReceipt checks the validation info of the transaction in the receipt
+func validateReceipt(txID string, receipt *types.TxReceipt) error {
+	valInfo := receipt.GetHeader().GetValidationInfo()
+	if receipt.GetTxIndex() >= uint64(len(valInfo)) {
+		return errors.Errorf("receipt of transaction [%s] has no validation info for tx index %d", txID, receipt.GetTxIndex())
+	}
+	txValInfo := valInfo[receipt.GetTxIndex()]
+	if txValInfo.GetFlag() != types.Flag_VALID {
+		return &TxInvalidError{
+			TxID:   txID,
+			Flag:   txValInfo.GetFlag(),
+			Reason: txValInfo.GetReasonIfInvalid(),
+		}
+	}
+	return nil
+}
+
+// isPermanentError checks whenever request failure won't be resolved by retrying the request
+func isPermanentError(err error) bool {
+	return errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrBadSignature) || errors.Is(err, ErrUnknownNode) ||
+		errors.Is(err, ErrSessionClosed)
+}
@@ -0,0 +1,182 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/bcdb/mocks"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     *ServerError
+		errMsg  string
+		wrapped error
+	}{
+		{
+			name: "query not found",
+			err: &ServerError{
+				StatusCode: http.StatusNotFound,
+				Status:     "404 Not Found",
+				Message:    "block not found: 100",
+				ReplicaID:  "node1",
+			},
+			errMsg:  "error handling request, server returned: status: 404 Not Found, message: block not found: 100",
+			wrapped: ErrNotFound,
+		},
+		{
+			name: "query forbidden",
+			err: &ServerError{
+				StatusCode: http.StatusForbidden,
+				Status:     "403 Forbidden",
+				Message:    "no permission",
+				ReplicaID:  "node1",
+			},
+			errMsg:  "error handling request, server returned: status: 403 Forbidden, message: no permission",
+			wrapped: ErrPermissionDenied,
+		},
+		{
+			name: "submit bad request",
+			err: &ServerError{
+				StatusCode: http.StatusBadRequest,
+				Status:     "400 Bad Request",
+				Message:    "bad request",
+				ReplicaID:  "node1",
+				TxID:       "tx1",
+			},
+			errMsg: "failed to submit transaction, server returned: status: 400 Bad Request, message: bad request",
+		},
+		{
+			name: "submit duplicate",
+			err: &ServerError{
+				StatusCode: http.StatusBadRequest,
+				Status:     "400 Bad Request",
+				Message:    "the transaction has a duplicate txID [tx1]",
+				ReplicaID:  "node1",
+				TxID:       "tx1",
+			},
+			errMsg:  "failed to submit transaction, server returned: status: 400 Bad Request, message: the transaction has a duplicate txID [tx1]",
+			wrapped: ErrDuplicateTxID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.EqualError(t, tt.err, tt.errMsg)
+			if tt.wrapped != nil {
+				require.True(t, errors.Is(tt.err, tt.wrapped))
+			} else {
+				require.Nil(t, errors.Unwrap(tt.err))
+			}
+		})
+	}
+}
+
+func TestTxInvalidError(t *testing.T) {
+	tests := []struct {
+		flag    types.Flag
+		wrapped error
+	}{
+		{flag: types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE, wrapped: ErrMVCCConflict},
+		{flag: types.Flag_INVALID_MVCC_CONFLICT_WITHIN_BLOCK, wrapped: ErrMVCCConflict},
+		{flag: types.Flag_INVALID_DB_NOT_EXIST, wrapped: ErrDBNotExist},
+		{flag: types.Flag_INVALID_NO_PERMISSION, wrapped: ErrPermissionDenied},
+		{flag: types.Flag_INVALID_UNAUTHORISED, wrapped: ErrPermissionDenied},
+		{flag: types.Flag_INVALID_INCORRECT_ENTRIES},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag.String(), func(t *testing.T) {
+			err := validateReceipt("tx1", receiptWithFlag(tt.flag, "reason"))
+			invalidErr := &TxInvalidError{}
+			require.True(t, errors.As(err, &invalidErr))
+			require.Equal(t, "tx1", invalidErr.TxID)
+			require.Equal(t, tt.flag, invalidErr.Flag)
+			require.Equal(t, "reason", invalidErr.Reason)
+			if tt.wrapped != nil {
+				require.True(t, errors.Is(err, tt.wrapped))
+			} else {
+				require.Nil(t, errors.Unwrap(err))
+			}
+		})
+	}
+
+	require.NoError(t, validateReceipt("tx1", receiptWithFlag(types.Flag_VALID, "")))
+}
+
+func TestTxCommit_TypedErrors(t *testing.T) {
+	emptySigner := &mocks.Signer{}
+	emptySigner.On("Sign", mock.Anything).Return([]byte{1}, nil)
+	logger := createTestLogger(t)
+
+	newDataTx := func(resp *http.Response) *dataTxContext {
+		return &dataTxContext{
+			commonTxContext: &commonTxContext{
+				userID:   "testUser",
+				signer:   emptySigner,
+				userCert: []byte{1, 2, 3},
+				replicaSet: map[string]*url.URL{
+					"node1": {
+						Path: "http://localhost:8888",
+					},
+				},
+				nodesCerts: testNodesCerts(),
+				restClient: NewRestClient("testUser", &mockHttpClient{
+					process: asyncSubmit,
+					resp:    resp,
+				}, emptySigner),
+				logger: logger,
+			},
+			operations: map[string]*dbOperations{},
+		}
+	}
+
+	dataTx := newDataTx(serverBadRequestResponse())
+	txID, _, err := dataTx.Commit(false)
+	serverErr := &ServerError{}
+	require.True(t, errors.As(err, &serverErr))
+	require.Equal(t, http.StatusBadRequest, serverErr.StatusCode)
+	require.Equal(t, "Bad request error", serverErr.Message)
+	require.Equal(t, "node1", serverErr.ReplicaID)
+	require.Equal(t, txID, serverErr.TxID)
+
+	dataTx = newDataTx(okResponseWithFlag(types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE))
+	txID, receipt, err := dataTx.Commit(false)
+	require.NotNil(t, receipt)
+	require.True(t, errors.Is(err, ErrMVCCConflict))
+	invalidErr := &TxInvalidError{}
+	require.True(t, errors.As(err, &invalidErr))
+	require.Equal(t, txID, invalidErr.TxID)
+	require.Equal(t, "node1", invalidErr.ReplicaID)
+}
+
+func okResponseWithFlag(flag types.Flag) *http.Response {
+	okResp := &types.ResponseEnvelope{
+		Payload: MarshalOrPanic(&types.Payload{
+			Header: &types.ResponseHeader{
+				NodeID: "node1",
+			},
+			Response: MarshalOrPanic(&types.TxResponse{
+				Receipt: receiptWithFlag(flag, "reason"),
+			}),
+		}),
+	}
+	okResp.Signature = testNodeIdentity.sign(okResp.Payload)
+	okPbJson, _ := json.Marshal(okResp)
+	return &http.Response{
+		StatusCode: 200,
+		Status:     http.StatusText(200),
+		Body:       ioutil.NopCloser(bytes.NewReader(okPbJson)),
+	}
+}
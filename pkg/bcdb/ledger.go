@@ -3,8 +3,12 @@
 package bcdb
 
 import (
+	"context"
+
 	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
 )
 
 type ledger struct {
@@ -12,9 +16,24 @@ type ledger struct {
 }
 
 func (l *ledger) GetBlockHeader(blockNum uint64) (*types.BlockHeader, error) {
+	return l.GetBlockHeaderCtx(context.Background(), blockNum)
+}
+
+func (l *ledger) GetBlockHeaderCtx(ctx context.Context, blockNum uint64) (*types.BlockHeader, error) {
+	header, err := l.getBlockHeader(ctx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if err = l.verifyHeader(ctx, header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (l *ledger) getBlockHeader(ctx context.Context, blockNum uint64) (*types.BlockHeader, error) {
 	path := constants.URLForLedgerBlock(blockNum)
 	res := &types.GetBlockResponse{}
-	err := l.handleRequest(path, &types.GetBlockQuery{
+	err := l.handleRequest(ctx, path, &types.GetBlockQuery{
 		UserID:      l.userID,
 		BlockNumber: blockNum,
 	}, res)
@@ -26,9 +45,34 @@ func (l *ledger) GetBlockHeader(blockNum uint64) (*types.BlockHeader, error) {
 }
 
 func (l *ledger) GetLedgerPath(startBlock, endBlock uint64) ([]*types.BlockHeader, error) {
+	return l.GetLedgerPathCtx(context.Background(), startBlock, endBlock)
+}
+
+func (l *ledger) GetLedgerPathCtx(ctx context.Context, startBlock, endBlock uint64) ([]*types.BlockHeader, error) {
+	headers, err := l.getLedgerPath(ctx, startBlock, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkLedgerPathEnds(headers, startBlock, endBlock); err != nil {
+		l.logger.Errorf("ledger path from block %d to block %d is invalid, due to %s", startBlock, endBlock, err)
+		return nil, err
+	}
+	if l.ledgerVerifier != nil {
+		// Path headers are trusted if path is consistent and its end block extends the checkpoint
+		if err = VerifyLedgerPath(headers, headers[len(headers)-1], endBlock); err != nil {
+			return nil, err
+		}
+		if err = l.verifyHeader(ctx, headers[0]); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+func (l *ledger) getLedgerPath(ctx context.Context, startBlock, endBlock uint64) ([]*types.BlockHeader, error) {
 	path := constants.URLForLedgerPath(startBlock, endBlock)
 	res := &types.GetLedgerPathResponse{}
-	err := l.handleRequest(path, &types.GetLedgerPathQuery{
+	err := l.handleRequest(ctx, path, &types.GetLedgerPathQuery{
 		UserID:           l.userID,
 		StartBlockNumber: startBlock,
 		EndBlockNumber:   endBlock,
@@ -41,9 +85,17 @@ func (l *ledger) GetLedgerPath(startBlock, endBlock uint64) ([]*types.BlockHeade
 }
 
 func (l *ledger) GetTransactionProof(blockNum uint64, txIndex int) (*TxProof, error) {
+	return l.GetTransactionProofCtx(context.Background(), blockNum, txIndex)
+}
+
+func (l *ledger) GetTransactionProofCtx(ctx context.Context, blockNum uint64, txIndex int) (*TxProof, error) {
+	return l.getTransactionProof(ctx, blockNum, txIndex)
+}
+
+func (l *ledger) getTransactionProof(ctx context.Context, blockNum uint64, txIndex int) (*TxProof, error) {
 	path := constants.URLTxProof(blockNum, txIndex)
 	res := &types.GetTxProofResponse{}
-	err := l.handleRequest(path, &types.GetTxProofQuery{
+	err := l.handleRequest(ctx, path, &types.GetTxProofQuery{
 		UserID:      l.userID,
 		BlockNumber: blockNum,
 		TxIndex:     uint64(txIndex),
@@ -56,9 +108,13 @@ func (l *ledger) GetTransactionProof(blockNum uint64, txIndex int) (*TxProof, er
 }
 
 func (l *ledger) GetTransactionReceipt(txId string) (*types.TxReceipt, error) {
+	return l.GetTransactionReceiptCtx(context.Background(), txId)
+}
+
+func (l *ledger) GetTransactionReceiptCtx(ctx context.Context, txId string) (*types.TxReceipt, error) {
 	path := constants.URLForGetTransactionReceipt(txId)
 	res := &types.TxResponse{}
-	err := l.handleRequest(path, &types.GetTxReceiptQuery{
+	err := l.handleRequest(ctx, path, &types.GetTxReceiptQuery{
 		UserID: l.userID,
 		TxID:   txId,
 	}, res)
@@ -66,6 +122,40 @@ func (l *ledger) GetTransactionReceipt(txId string) (*types.TxReceipt, error) {
 		l.logger.Errorf("failed to execute transaction receipt query %s, due to %s", path, err)
 		return nil, err
 	}
+	if err = l.verifyHeader(ctx, res.GetReceipt().GetHeader()); err != nil {
+		return nil, err
+	}
 
 	return res.GetReceipt(), nil
 }
+
+func (l *ledger) VerifyTx(envelope proto.Message, receipt *types.TxReceipt) (*TxVerificationReport, error) {
+	return l.VerifyTxCtx(context.Background(), envelope, receipt)
+}
+
+func (l *ledger) VerifyTxCtx(ctx context.Context, envelope proto.Message, receipt *types.TxReceipt) (*TxVerificationReport, error) {
+	return l.verifyTx(ctx, nil, envelope, receipt)
+}
+
+func (l *ledger) VerifyTxFrom(trusted *types.BlockHeader, envelope proto.Message, receipt *types.TxReceipt) (*TxVerificationReport, error) {
+	return l.VerifyTxFromCtx(context.Background(), trusted, envelope, receipt)
+}
+
+func (l *ledger) VerifyTxFromCtx(ctx context.Context, trusted *types.BlockHeader, envelope proto.Message, receipt *types.TxReceipt) (*TxVerificationReport, error) {
+	if trusted == nil {
+		return nil, errors.New("trusted block header must be provided")
+	}
+	return l.verifyTx(ctx, trusted, envelope, receipt)
+}
+
+func (l *ledger) verifyTx(ctx context.Context, trusted *types.BlockHeader, envelope proto.Message, receipt *types.TxReceipt) (*TxVerificationReport, error) {
+	report, err := verifyTx(ctx, l, l.ledgerVerifier, trusted, envelope, receipt)
+	if err != nil {
+		l.logger.Errorf("failed to verify transaction, due to %s", err)
+		return nil, err
+	}
+	if !report.Verified() {
+		l.logger.Debugf("transaction [%s] verification failed: %v", report.TxID, report.Failures)
+	}
+	return report, nil
+}
@@ -0,0 +1,116 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/crypto"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// ErrLedgerPathBroken returned when ledger path doesn't connect the blocks it claims to connect
+var ErrLedgerPathBroken = errors.New("ledger path verification failed")
+
+// LedgerPathError describes the broken link of the ledger path
+type LedgerPathError struct {
+	// Link index of the broken link, link i connects headers[i] and headers[i+1]
+	Link int
+	// FromBlock number of the block link starts from, i.e. the later block
+	FromBlock uint64
+	// ToBlock number of the block link points to, i.e. the earlier block
+	ToBlock uint64
+	Reason  string
+}
+
+func (e *LedgerPathError) Error() string {
+	return fmt.Sprintf("%s: link %d from block %d to block %d: %s", ErrLedgerPathBroken, e.Link, e.FromBlock, e.ToBlock, e.Reason)
+}
+
+func (e *LedgerPathError) Unwrap() error {
+	return ErrLedgerPathBroken
+}
+
+// ComputeBlockHeaderHash computes the hash of the block header, as referenced by skip list
+// links of the later blocks
+func ComputeBlockHeaderHash(header *types.BlockHeader) ([]byte, error) {
+	headerBytes, err := proto.Marshal(header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't serialize block header [%d]", header.GetBaseHeader().GetNumber())
+	}
+	return crypto.ComputeSHA256Hash(headerBytes)
+}
+
+// VerifyLedgerPath verifies the path returned by Ledger.GetLedgerPath: headers ordered from
+// the end block to the start block, the first header should be the header of endBlock, each header
+// should reference the hash of the next header in its skip list links and the last header should be
+// equal to trustedStart. *LedgerPathError returned if path is broken.
+func VerifyLedgerPath(headers []*types.BlockHeader, trustedStart *types.BlockHeader, endBlock uint64) error {
+	if trustedStart == nil {
+		return errors.New("trusted start block header is nil")
+	}
+	if err := checkLedgerPathEnds(headers, trustedStart.GetBaseHeader().GetNumber(), endBlock); err != nil {
+		return err
+	}
+
+	trustedHash, err := ComputeBlockHeaderHash(trustedStart)
+	if err != nil {
+		return err
+	}
+	last := headers[len(headers)-1]
+	lastHash, err := ComputeBlockHeaderHash(last)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(trustedHash, lastHash) {
+		return errors.WithMessagef(ErrLedgerPathBroken, "path ends at block %d, which doesn't match trusted block %d",
+			last.GetBaseHeader().GetNumber(), trustedStart.GetBaseHeader().GetNumber())
+	}
+
+	for i := 0; i < len(headers)-1; i++ {
+		from, to := headers[i], headers[i+1]
+		if err = verifyLedgerPathLink(from, to); err != nil {
+			return &LedgerPathError{
+				Link:      i,
+				FromBlock: from.GetBaseHeader().GetNumber(),
+				ToBlock:   to.GetBaseHeader().GetNumber(),
+				Reason:    err.Error(),
+			}
+		}
+	}
+	return nil
+}
+
+// checkLedgerPathEnds checks that the path starts at endBlock and ends at startBlock
+func checkLedgerPathEnds(headers []*types.BlockHeader, startBlock, endBlock uint64) error {
+	if len(headers) == 0 {
+		return errors.WithMessage(ErrLedgerPathBroken, "ledger path is empty")
+	}
+	if first := headers[0].GetBaseHeader().GetNumber(); first != endBlock {
+		return errors.WithMessagef(ErrLedgerPathBroken, "path starts at block %d instead of end block %d", first, endBlock)
+	}
+	if last := headers[len(headers)-1].GetBaseHeader().GetNumber(); last != startBlock {
+		return errors.WithMessagef(ErrLedgerPathBroken, "path ends at block %d instead of start block %d", last, startBlock)
+	}
+	return nil
+}
+
+// verifyLedgerPathLink checks that the later block header references the earlier block header
+func verifyLedgerPathLink(from, to *types.BlockHeader) error {
+	if from.GetBaseHeader().GetNumber() <= to.GetBaseHeader().GetNumber() {
+		return errors.New("block numbers are not decreasing")
+	}
+	toHash, err := ComputeBlockHeaderHash(to)
+	if err != nil {
+		return err
+	}
+	for _, linkHash := range from.GetSkipchainHashes() {
+		if bytes.Equal(linkHash, toHash) {
+			return nil
+		}
+	}
+	return errors.New("block hash is not referenced by skip list links")
+}
@@ -0,0 +1,211 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/bcdb/mocks"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/crypto"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testSkipListLinks returns numbers of blocks referenced by block skip list links,
+// block n references blocks n-1, n-2, n-4, ... while (n-1) divisible by the distance
+func testSkipListLinks(blockNum uint64) []uint64 {
+	var links []uint64
+	if blockNum <= 1 {
+		return links
+	}
+	for distance := uint64(1); (blockNum-1)%distance == 0 && distance < blockNum; distance *= 2 {
+		links = append(links, blockNum-distance)
+	}
+	return links
+}
+
+// testLedgerChain builds chain of n block headers, chain[i] is the header of block i+1
+func testLedgerChain(t *testing.T, n int) []*types.BlockHeader {
+	return testExtendLedgerChain(t, nil, n, 0)
+}
+
+// testExtendLedgerChain appends block headers to the copy of the chain up to n blocks,
+// chains extended with different seeds fork after the last common block
+func testExtendLedgerChain(t *testing.T, chain []*types.BlockHeader, n int, seed byte) []*types.BlockHeader {
+	chain = append([]*types.BlockHeader{}, chain...)
+	for i := len(chain) + 1; i <= n; i++ {
+		txRoot, err := crypto.ComputeSHA256Hash([]byte{byte(i), seed})
+		require.NoError(t, err)
+		header := &types.BlockHeader{
+			BaseHeader: &types.BlockHeaderBase{
+				Number: uint64(i),
+			},
+			TxMerkelTreeRootHash: txRoot,
+			ValidationInfo: []*types.ValidationInfo{
+				{
+					Flag: types.Flag_VALID,
+				},
+			},
+		}
+		for _, link := range testSkipListLinks(uint64(i)) {
+			linkHash, err := ComputeBlockHeaderHash(chain[link-1])
+			require.NoError(t, err)
+			header.SkipchainHashes = append(header.SkipchainHashes, linkHash)
+		}
+		chain = append(chain, header)
+	}
+	return chain
+}
+
+// testLedgerPath returns path from end block to start block, as returned by the server
+func testLedgerPath(chain []*types.BlockHeader, start, end uint64) []*types.BlockHeader {
+	path := []*types.BlockHeader{chain[end-1]}
+	for curr := end; curr > start; {
+		next := curr - 1
+		for _, link := range testSkipListLinks(curr) {
+			if link >= start && link < next {
+				next = link
+			}
+		}
+		path = append(path, chain[next-1])
+		curr = next
+	}
+	return path
+}
+
+func TestVerifyLedgerPath(t *testing.T) {
+	chain := testLedgerChain(t, 20)
+
+	tests := []struct {
+		name  string
+		start uint64
+		end   uint64
+	}{
+		{name: "from 3 to 2", start: 2, end: 3},
+		{name: "from 6 to 1", start: 1, end: 6},
+		{name: "from 17 to 1", start: 1, end: 17},
+		{name: "from 20 to 3", start: 3, end: 20},
+		{name: "single block", start: 5, end: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := testLedgerPath(chain, tt.start, tt.end)
+			require.NoError(t, VerifyLedgerPath(path, chain[tt.start-1], tt.end))
+		})
+	}
+
+	path := testLedgerPath(chain, 1, 17)
+	require.Len(t, path, 2)
+	path = testLedgerPath(chain, 1, 6)
+	require.Len(t, path, 3)
+	require.Equal(t, uint64(5), path[1].GetBaseHeader().GetNumber())
+}
+
+func TestVerifyLedgerPath_Broken(t *testing.T) {
+	chain := testLedgerChain(t, 20)
+
+	t.Run("empty path", func(t *testing.T) {
+		err := VerifyLedgerPath(nil, chain[0], 1)
+		require.True(t, errors.Is(err, ErrLedgerPathBroken))
+	})
+
+	t.Run("untrusted start", func(t *testing.T) {
+		path := testLedgerPath(chain, 1, 17)
+		forkedGenesis := proto.Clone(chain[0]).(*types.BlockHeader)
+		forkedGenesis.TxMerkelTreeRootHash = []byte{1}
+		err := VerifyLedgerPath(path, forkedGenesis, 17)
+		require.True(t, errors.Is(err, ErrLedgerPathBroken))
+		require.Contains(t, err.Error(), "path ends at block 1, which doesn't match trusted block 1")
+	})
+
+	t.Run("wrong end block", func(t *testing.T) {
+		path := testLedgerPath(chain, 1, 17)
+		err := VerifyLedgerPath(path, chain[0], 20)
+		require.True(t, errors.Is(err, ErrLedgerPathBroken))
+		require.Contains(t, err.Error(), "path starts at block 17 instead of end block 20")
+	})
+
+	t.Run("wrong start block", func(t *testing.T) {
+		path := testLedgerPath(chain, 3, 20)
+		err := VerifyLedgerPath(path, chain[0], 20)
+		require.True(t, errors.Is(err, ErrLedgerPathBroken))
+		require.Contains(t, err.Error(), "path ends at block 3 instead of start block 1")
+	})
+
+	t.Run("tampered header", func(t *testing.T) {
+		path := testLedgerPath(chain, 1, 6)
+		require.Len(t, path, 3)
+		path[1] = proto.Clone(path[1]).(*types.BlockHeader)
+		path[1].TxMerkelTreeRootHash = []byte{1}
+
+		err := VerifyLedgerPath(path, chain[0], 6)
+		pathErr := &LedgerPathError{}
+		require.True(t, errors.As(err, &pathErr))
+		require.True(t, errors.Is(err, ErrLedgerPathBroken))
+		require.Equal(t, 0, pathErr.Link)
+		require.Equal(t, uint64(6), pathErr.FromBlock)
+		require.Equal(t, uint64(5), pathErr.ToBlock)
+		require.Equal(t, "block hash is not referenced by skip list links", pathErr.Reason)
+	})
+
+	t.Run("reverse order", func(t *testing.T) {
+		path := []*types.BlockHeader{chain[1], chain[2], chain[1]}
+		err := VerifyLedgerPath(path, chain[1], 2)
+		pathErr := &LedgerPathError{}
+		require.True(t, errors.As(err, &pathErr))
+		require.Equal(t, 0, pathErr.Link)
+		require.Equal(t, "block numbers are not decreasing", pathErr.Reason)
+	})
+}
+
+func TestGetLedgerPath_WrongEnds(t *testing.T) {
+	chain := testLedgerChain(t, 20)
+	ca := newTestCA(t, "ca")
+	nodeCert, nodeKey := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "node1"}})
+	node := &testNode{id: "node1", cert: nodeCert, key: nodeKey}
+
+	signer := &mocks.Signer{}
+	signer.On("Sign", mock.Anything).Return([]byte{1}, nil)
+	// Server returns consistent path, which connects other blocks than requested
+	httpClient := &mockHttpClient{
+		process: func(req *http.Request, resp *http.Response) (*http.Response, error) {
+			return signedResponse(node, &types.GetLedgerPathResponse{
+				BlockHeaders: testLedgerPath(chain, 3, 17),
+			}), nil
+		},
+	}
+	l := &ledger{
+		commonTxContext: &commonTxContext{
+			userID: "alice",
+			signer: signer,
+			replicaSet: map[string]*url.URL{
+				"node1": {
+					Path: "http://localhost:8888",
+				},
+			},
+			nodesCerts: map[string]*x509.Certificate{node.id: node.cert},
+			restClient: NewRestClient("alice", httpClient, signer),
+			logger:     createTestLogger(t),
+		},
+	}
+
+	path, err := l.GetLedgerPath(3, 17)
+	require.NoError(t, err)
+	require.Equal(t, uint64(17), path[0].GetBaseHeader().GetNumber())
+	require.Equal(t, uint64(3), path[len(path)-1].GetBaseHeader().GetNumber())
+
+	_, err = l.GetLedgerPath(3, 20)
+	require.True(t, errors.Is(err, ErrLedgerPathBroken))
+	require.Contains(t, err.Error(), "path starts at block 17 instead of end block 20")
+
+	_, err = l.GetLedgerPath(1, 17)
+	require.True(t, errors.Is(err, ErrLedgerPathBroken))
+	require.Contains(t, err.Error(), "path ends at block 3 instead of start block 1")
+}
@@ -3,10 +3,14 @@
 package bcdb
 
 import (
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"path"
 	"testing"
 	"time"
 
+	sdkconfig "github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
 	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/require"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/server/testutils"
@@ -38,6 +42,57 @@ func TestGetBlockHeader(t *testing.T) {
 	require.Nil(t, header)
 }
 
+func TestLedgerVerification(t *testing.T) {
+	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "server"})
+	testServer, _, _, err := SetupTestServer(t, clientCertTemDir)
+	defer testServer.Stop()
+	require.NoError(t, err)
+	bcdb, _, aliceSession := startServerConnectOpenAdminCreateUserAndUserSession(t, testServer, clientCertTemDir, "alice")
+
+	checkpointPath := path.Join(clientCertTemDir, "checkpoint.json")
+	verifiedSession, err := bcdb.Session(&sdkconfig.SessionConfig{
+		UserConfig: &sdkconfig.UserConfig{
+			UserID:         "alice",
+			CertPath:       path.Join(clientCertTemDir, "alice.pem"),
+			PrivateKeyPath: path.Join(clientCertTemDir, "alice.key"),
+		},
+		TxTimeout: time.Second * 2,
+		LedgerVerification: &sdkconfig.LedgerVerificationConfig{
+			CheckpointPath: checkpointPath,
+		},
+	})
+	require.NoError(t, err)
+
+	for i := 1; i < 5; i++ {
+		putKeySync(t, "bdb", fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i), "alice", verifiedSession)
+	}
+
+	store := NewFileCheckpointStore(checkpointPath)
+	checkpoint, err := store.Load()
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	lastCommitted := checkpoint.GetBaseHeader().GetNumber()
+	require.True(t, lastCommitted > 1)
+
+	for i := 5; i < 10; i++ {
+		putKeySync(t, "bdb", fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i), "alice", aliceSession)
+	}
+
+	l, err := verifiedSession.Ledger()
+	require.NoError(t, err)
+	header, err := l.GetBlockHeader(lastCommitted + 3)
+	require.NoError(t, err)
+	checkpoint, err = store.Load()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(header, checkpoint))
+
+	_, err = l.GetLedgerPath(1, lastCommitted+1)
+	require.NoError(t, err)
+	checkpoint, err = store.Load()
+	require.NoError(t, err)
+	require.Equal(t, lastCommitted+3, checkpoint.GetBaseHeader().GetNumber())
+}
+
 func TestGetLedgerPath(t *testing.T) {
 	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "server"})
 	testServer, _, _, err := SetupTestServer(t, clientCertTemDir)
@@ -109,6 +164,7 @@ func TestGetLedgerPath(t *testing.T) {
 				for i, b := range tt.path {
 					require.True(t, proto.Equal(b, path[i]), fmt.Sprintf("Expected block number %d, actual block number %d", b.GetBaseHeader().GetNumber(), path[i].GetBaseHeader().GetNumber()))
 				}
+				require.NoError(t, VerifyLedgerPath(path, existBlocks[tt.start-1], tt.end))
 			} else {
 				require.EqualError(t, err, tt.errMessage)
 			}
@@ -191,6 +247,110 @@ func TestGetTransactionProof(t *testing.T) {
 	}
 }
 
+func TestLedger_VerifyTx(t *testing.T) {
+	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "server"})
+	testServer, _, _, err := SetupTestServer(t, clientCertTemDir)
+	defer testServer.Stop()
+	require.NoError(t, err)
+	_, _, aliceSession := startServerConnectOpenAdminCreateUserAndUserSession(t, testServer, clientCertTemDir, "alice")
+
+	tx, err := aliceSession.DataTx()
+	require.NoError(t, err)
+	require.NoError(t, tx.Put("bdb", "key1", []byte("value1"), nil))
+	txID, receipt, err := tx.Commit(true)
+	require.NoError(t, err)
+	txEnv, err := tx.TxEnvelope()
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		putKeySync(t, "bdb", fmt.Sprintf("key%d", i+2), fmt.Sprintf("value%d", i+2), "alice", aliceSession)
+	}
+
+	l, err := aliceSession.Ledger()
+	require.NoError(t, err)
+
+	// session doesn't verify the ledger, genesis block returned by the server isn't trusted
+	report, err := l.VerifyTx(txEnv, receipt)
+	require.NoError(t, err)
+	require.False(t, report.Verified())
+	require.True(t, report.Unanchored)
+	require.True(t, report.TxIncluded)
+	require.True(t, report.Valid)
+
+	// trusted genesis block header, e.g. obtained out of band
+	genesis, err := l.GetBlockHeader(1)
+	require.NoError(t, err)
+	report, err = l.VerifyTxFrom(genesis, txEnv, receipt)
+	require.NoError(t, err)
+	require.True(t, report.Verified())
+	require.Equal(t, txID, report.TxID)
+	require.Equal(t, receipt.GetHeader().GetBaseHeader().GetNumber(), report.BlockNumber)
+	require.Equal(t, uint64(1), report.TrustedBlockNumber)
+	require.Empty(t, report.Failures)
+
+	tamperedReceipt := &types.TxReceipt{
+		Header:  proto.Clone(receipt.GetHeader()).(*types.BlockHeader),
+		TxIndex: receipt.GetTxIndex(),
+	}
+	tamperedReceipt.Header.ValidationInfo[receipt.GetTxIndex()] = &types.ValidationInfo{
+		Flag:            types.Flag_INVALID_NO_PERMISSION,
+		ReasonIfInvalid: "tampered",
+	}
+	report, err = l.VerifyTxFrom(genesis, txEnv, tamperedReceipt)
+	require.NoError(t, err)
+	require.False(t, report.Verified())
+	require.False(t, report.TxIncluded)
+	require.False(t, report.HeaderInLedger)
+	require.False(t, report.Valid)
+	require.Len(t, report.Failures, 3)
+}
+
+func TestGetTransactionProof_AdminTransactions(t *testing.T) {
+	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "bob", "server"})
+	testServer, _, _, err := SetupTestServer(t, clientCertTemDir)
+	defer testServer.Stop()
+	require.NoError(t, err)
+	_, adminSession, _ := startServerConnectOpenAdminCreateUserAndUserSession(t, testServer, clientCertTemDir, "alice")
+
+	dbsTx, err := adminSession.DBsTx()
+	require.NoError(t, err)
+	require.NoError(t, dbsTx.CreateDB("db1"))
+	_, dbsReceipt, err := dbsTx.Commit(true)
+	require.NoError(t, err)
+	dbsTxEnv, err := dbsTx.TxEnvelope()
+	require.NoError(t, err)
+
+	pemUserCert, err := ioutil.ReadFile(path.Join(clientCertTemDir, "bob.pem"))
+	require.NoError(t, err)
+	certBlock, _ := pem.Decode(pemUserCert)
+	usersTx, err := adminSession.UsersTx()
+	require.NoError(t, err)
+	require.NoError(t, usersTx.PutUser(&types.User{
+		ID:          "bob",
+		Certificate: certBlock.Bytes,
+	}, nil))
+	_, usersReceipt, err := usersTx.Commit(true)
+	require.NoError(t, err)
+	usersTxEnv, err := usersTx.TxEnvelope()
+	require.NoError(t, err)
+
+	l, err := adminSession.Ledger()
+	require.NoError(t, err)
+	for _, tx := range []struct {
+		receipt *types.TxReceipt
+		env     proto.Message
+	}{
+		{receipt: dbsReceipt, env: dbsTxEnv},
+		{receipt: usersReceipt, env: usersTxEnv},
+	} {
+		proof, err := l.GetTransactionProof(tx.receipt.GetHeader().GetBaseHeader().GetNumber(), int(tx.receipt.GetTxIndex()))
+		require.NoError(t, err)
+		res, err := proof.Verify(tx.receipt, tx.env)
+		require.NoError(t, err)
+		require.True(t, res)
+	}
+}
+
 func TestGetTransactionReceipt(t *testing.T) {
 	clientCertTemDir := testutils.GenerateTestClientCrypto(t, []string{"admin", "alice", "server"})
 	testServer, _, _, err := SetupTestServerWithParams(t, clientCertTemDir, 5*time.Second, 10)
@@ -0,0 +1,244 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/logger"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// ErrLedgerForked returned when block header observed by the SDK doesn't extend trusted checkpoint,
+// i.e. the server presents forked or rewritten ledger
+var ErrLedgerForked = errors.New("block header doesn't extend trusted ledger checkpoint")
+
+// LedgerVerifier keeps trusted block header checkpoint and verifies that block headers
+// returned by the server are part of the same ledger, checkpoint advanced to the latest verified header
+type LedgerVerifier interface {
+	// Checkpoint returns current trusted block header, genesis block header is fetched and
+	// trusted if checkpoint store is empty
+	Checkpoint(ctx context.Context) (*types.BlockHeader, error)
+	// VerifyHeader verifies that header is connected to the checkpoint by verified ledger path,
+	// checkpoint advanced if header is newer. Error wrapping ErrLedgerForked returned otherwise
+	VerifyHeader(ctx context.Context, header *types.BlockHeader) error
+}
+
+// ledgerSource provides block headers and ledger paths, without verifying them
+type ledgerSource interface {
+	getBlockHeader(ctx context.Context, blockNum uint64) (*types.BlockHeader, error)
+	getLedgerPath(ctx context.Context, startBlock, endBlock uint64) ([]*types.BlockHeader, error)
+}
+
+type ledgerVerifier struct {
+	mu         sync.Mutex
+	source     func(ctx context.Context) (ledgerSource, error)
+	store      config.CheckpointStore
+	checkpoint *types.BlockHeader
+	logger     *logger.SugarLogger
+}
+
+// NewLedgerVerifier creates ledger verifier, which uses l to fetch ledger paths and stores checkpoint in store
+func NewLedgerVerifier(l Ledger, store config.CheckpointStore) (LedgerVerifier, error) {
+	if l == nil || store == nil {
+		return nil, errors.New("ledger and checkpoint store must be provided")
+	}
+	var source ledgerSource
+	var lg *logger.SugarLogger
+	if sdkLedger, ok := l.(*ledger); ok {
+		source = sdkLedger
+		lg = sdkLedger.logger
+	} else {
+		source = &publicLedgerSource{l}
+	}
+	return newLedgerVerifier(func(context.Context) (ledgerSource, error) {
+		return source, nil
+	}, store, lg), nil
+}
+
+func newLedgerVerifier(source func(ctx context.Context) (ledgerSource, error), store config.CheckpointStore, logger *logger.SugarLogger) *ledgerVerifier {
+	return &ledgerVerifier{
+		source: source,
+		store:  store,
+		logger: logger,
+	}
+}
+
+func (v *ledgerVerifier) Checkpoint(ctx context.Context) (*types.BlockHeader, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.loadCheckpoint(ctx)
+}
+
+func (v *ledgerVerifier) VerifyHeader(ctx context.Context, header *types.BlockHeader) error {
+	if header == nil {
+		return errors.New("block header is nil")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	checkpoint, err := v.loadCheckpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	headerNum := header.GetBaseHeader().GetNumber()
+	checkpointNum := checkpoint.GetBaseHeader().GetNumber()
+	switch {
+	case headerNum == checkpointNum:
+		equal, err := headersEqual(header, checkpoint)
+		if err != nil {
+			return err
+		}
+		if !equal {
+			return errors.WithMessagef(ErrLedgerForked, "block %d differs from checkpoint", headerNum)
+		}
+		return nil
+
+	case headerNum > checkpointNum:
+		if err = v.verifyPath(ctx, checkpoint, header); err != nil {
+			return err
+		}
+		if err = v.store.Store(header); err != nil {
+			return errors.WithMessage(err, "failed to store ledger checkpoint")
+		}
+		v.checkpoint = header
+		if v.logger != nil {
+			v.logger.Debugf("ledger checkpoint advanced from block %d to block %d", checkpointNum, headerNum)
+		}
+		return nil
+
+	default:
+		return v.verifyPath(ctx, header, checkpoint)
+	}
+}
+
+// verifyPath verifies that the later header is connected to the earlier header by the ledger path
+func (v *ledgerVerifier) verifyPath(ctx context.Context, earlier, later *types.BlockHeader) error {
+	source, err := v.source(ctx)
+	if err != nil {
+		return err
+	}
+	path, err := source.getLedgerPath(ctx, earlier.GetBaseHeader().GetNumber(), later.GetBaseHeader().GetNumber())
+	if err != nil {
+		return errors.WithMessage(err, "failed to fetch ledger path")
+	}
+	if err = VerifyLedgerPath(path, earlier, later.GetBaseHeader().GetNumber()); err != nil {
+		return errors.WithMessagef(ErrLedgerForked, "block %d is not connected to block %d: %s",
+			later.GetBaseHeader().GetNumber(), earlier.GetBaseHeader().GetNumber(), err)
+	}
+	equal, err := headersEqual(path[0], later)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		return errors.WithMessagef(ErrLedgerForked, "ledger path doesn't lead to block %d", later.GetBaseHeader().GetNumber())
+	}
+	return nil
+}
+
+// loadCheckpoint returns current checkpoint, loads it from the store or trusts genesis block if store is empty
+func (v *ledgerVerifier) loadCheckpoint(ctx context.Context) (*types.BlockHeader, error) {
+	if v.checkpoint != nil {
+		return v.checkpoint, nil
+	}
+
+	checkpoint, err := v.store.Load()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load ledger checkpoint")
+	}
+	if checkpoint == nil {
+		source, err := v.source(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if checkpoint, err = source.getBlockHeader(ctx, 1); err != nil {
+			return nil, errors.WithMessage(err, "failed to fetch genesis block header")
+		}
+		if err = v.store.Store(checkpoint); err != nil {
+			return nil, errors.WithMessage(err, "failed to store ledger checkpoint")
+		}
+	}
+	v.checkpoint = checkpoint
+	return checkpoint, nil
+}
+
+func headersEqual(h1, h2 *types.BlockHeader) (bool, error) {
+	hash1, err := ComputeBlockHeaderHash(h1)
+	if err != nil {
+		return false, err
+	}
+	hash2, err := ComputeBlockHeaderHash(h2)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(hash1, hash2), nil
+}
+
+// publicLedgerSource adapts Ledger implementation to ledgerSource
+type publicLedgerSource struct {
+	l Ledger
+}
+
+func (s *publicLedgerSource) getBlockHeader(ctx context.Context, blockNum uint64) (*types.BlockHeader, error) {
+	return s.l.GetBlockHeaderCtx(ctx, blockNum)
+}
+
+func (s *publicLedgerSource) getLedgerPath(ctx context.Context, startBlock, endBlock uint64) ([]*types.BlockHeader, error) {
+	return s.l.GetLedgerPathCtx(ctx, startBlock, endBlock)
+}
+
+type fileCheckpointStore struct {
+	path string
+}
+
+// NewFileCheckpointStore returns checkpoint store, which keeps checkpoint in the file
+func NewFileCheckpointStore(path string) config.CheckpointStore {
+	return &fileCheckpointStore{path: path}
+}
+
+func (s *fileCheckpointStore) Load() (*types.BlockHeader, error) {
+	headerBytes, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	header := &types.BlockHeader{}
+	if err = json.Unmarshal(headerBytes, header); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal checkpoint from %s", s.path)
+	}
+	return header, nil
+}
+
+func (s *fileCheckpointStore) Store(header *types.BlockHeader) error {
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	// Write to temporary file and rename, checkpoint file is never left partially written
+	tmpFile, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err = tmpFile.Write(headerBytes); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), s.path)
+}
@@ -0,0 +1,145 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+type testLedgerSource struct {
+	chain    []*types.BlockHeader
+	requests int
+}
+
+func (s *testLedgerSource) getBlockHeader(_ context.Context, blockNum uint64) (*types.BlockHeader, error) {
+	s.requests++
+	if blockNum == 0 || blockNum > uint64(len(s.chain)) {
+		return nil, errors.New("block not found")
+	}
+	return s.chain[blockNum-1], nil
+}
+
+func (s *testLedgerSource) getLedgerPath(_ context.Context, startBlock, endBlock uint64) ([]*types.BlockHeader, error) {
+	s.requests++
+	if startBlock > endBlock || endBlock > uint64(len(s.chain)) {
+		return nil, errors.New("can't find path")
+	}
+	return testLedgerPath(s.chain, startBlock, endBlock), nil
+}
+
+type memoryCheckpointStore struct {
+	header *types.BlockHeader
+}
+
+func (s *memoryCheckpointStore) Load() (*types.BlockHeader, error) {
+	return s.header, nil
+}
+
+func (s *memoryCheckpointStore) Store(header *types.BlockHeader) error {
+	s.header = header
+	return nil
+}
+
+func newTestLedgerVerifier(source *testLedgerSource, store *memoryCheckpointStore) *ledgerVerifier {
+	return newLedgerVerifier(func(context.Context) (ledgerSource, error) {
+		return source, nil
+	}, store, nil)
+}
+
+func TestLedgerVerifier(t *testing.T) {
+	chain := testLedgerChain(t, 20)
+	ctx := context.Background()
+
+	t.Run("trust genesis and advance", func(t *testing.T) {
+		store := &memoryCheckpointStore{}
+		v := newTestLedgerVerifier(&testLedgerSource{chain: chain}, store)
+
+		checkpoint, err := v.Checkpoint(ctx)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(chain[0], checkpoint))
+		require.True(t, proto.Equal(chain[0], store.header))
+
+		require.NoError(t, v.VerifyHeader(ctx, chain[9]))
+		checkpoint, err = v.Checkpoint(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), checkpoint.GetBaseHeader().GetNumber())
+		require.True(t, proto.Equal(chain[9], store.header))
+
+		// Older and same headers verified, checkpoint not moved back
+		require.NoError(t, v.VerifyHeader(ctx, chain[4]))
+		require.NoError(t, v.VerifyHeader(ctx, chain[9]))
+		require.NoError(t, v.VerifyHeader(ctx, chain[16]))
+		require.Equal(t, uint64(17), store.header.GetBaseHeader().GetNumber())
+	})
+
+	t.Run("pinned checkpoint", func(t *testing.T) {
+		source := &testLedgerSource{chain: chain}
+		store := &memoryCheckpointStore{header: chain[7]}
+		v := newTestLedgerVerifier(source, store)
+
+		require.NoError(t, v.VerifyHeader(ctx, chain[2]))
+		require.NoError(t, v.VerifyHeader(ctx, chain[19]))
+		require.Equal(t, uint64(20), store.header.GetBaseHeader().GetNumber())
+		// genesis block never fetched
+		require.Equal(t, 2, source.requests)
+	})
+
+	t.Run("forked ledger", func(t *testing.T) {
+		forkedChain := testExtendLedgerChain(t, chain[:5], 20, 1)
+		store := &memoryCheckpointStore{header: chain[9]}
+		v := newTestLedgerVerifier(&testLedgerSource{chain: forkedChain}, store)
+
+		err := v.VerifyHeader(ctx, forkedChain[14])
+		require.True(t, errors.Is(err, ErrLedgerForked))
+		err = v.VerifyHeader(ctx, forkedChain[9])
+		require.True(t, errors.Is(err, ErrLedgerForked))
+		err = v.VerifyHeader(ctx, forkedChain[7])
+		require.True(t, errors.Is(err, ErrLedgerForked))
+		// Even common history can't be verified, since forked ledger doesn't lead to the checkpoint
+		err = v.VerifyHeader(ctx, forkedChain[2])
+		require.True(t, errors.Is(err, ErrLedgerForked))
+		require.True(t, proto.Equal(chain[9], store.header))
+	})
+
+	t.Run("header not matching path", func(t *testing.T) {
+		forkedChain := testExtendLedgerChain(t, chain[:5], 20, 1)
+		store := &memoryCheckpointStore{header: chain[0]}
+		v := newTestLedgerVerifier(&testLedgerSource{chain: chain}, store)
+
+		err := v.VerifyHeader(ctx, forkedChain[14])
+		require.True(t, errors.Is(err, ErrLedgerForked))
+		require.Contains(t, err.Error(), "ledger path doesn't lead to block 15")
+		require.True(t, proto.Equal(chain[0], store.header))
+	})
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	tempDir, err := ioutil.TempDir(os.TempDir(), "checkpointStoreTest")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	chain := testLedgerChain(t, 5)
+	store := NewFileCheckpointStore(path.Join(tempDir, "checkpoint.json"))
+	header, err := store.Load()
+	require.NoError(t, err)
+	require.Nil(t, header)
+
+	require.NoError(t, store.Store(chain[2]))
+	require.NoError(t, store.Store(chain[4]))
+	header, err = store.Load()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(chain[4], header))
+
+	files, err := ioutil.ReadDir(tempDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
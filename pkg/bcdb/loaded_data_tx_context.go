@@ -0,0 +1,243 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/cryptoservice"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// LoadedDataTxContext transaction context of data transaction constructed and
+// partially signed by other user, loaded in order to co-sign and/or submit it.
+// Flow:
+// 1. Initiator constructs transaction using DataTxContext, adds users that must sign it
+// with AddMustSignUser and exports envelope with SignConstructedTxEnvelopeAndCloseTx
+// 2. Each of must sign users loads envelope with DBSession.LoadDataTx, reviews
+// transaction operations and co-signs it with CoSignTxEnvelopeAndCloseTx
+// 3. Any party loads the envelope signed by all must sign users and commits it
+type LoadedDataTxContext interface {
+	// Embed general abstraction, Commit adds session user signature if
+	// session user must sign the transaction and didn't sign it yet
+	TxContext
+	// TxID returns ID of the loaded transaction
+	TxID() string
+	// MustSignUsers returns users that must sign the transaction
+	MustSignUsers() []string
+	// SignedUsers returns users that already signed the transaction
+	SignedUsers() []string
+	// Reads returns transaction reads, per database
+	Reads() map[string][]*types.DataRead
+	// Writes returns transaction writes, per database
+	Writes() map[string][]*types.DataWrite
+	// Deletes returns transaction deletes, per database
+	Deletes() map[string][]*types.DataDelete
+	// CoSignTxEnvelopeAndCloseTx adds session user signature to the envelope and returns it,
+	// the context is closed, no further operations are allowed
+	CoSignTxEnvelopeAndCloseTx() (proto.Message, error)
+}
+
+type loadedDataTxContext struct {
+	*commonTxContext
+	loadedEnvelope *types.DataTxEnvelope
+}
+
+func newLoadedDataTxContext(commonCtx *commonTxContext, env *types.DataTxEnvelope) (*loadedDataTxContext, error) {
+	if env == nil || env.GetPayload() == nil {
+		return nil, errors.New("transaction envelope or its payload is nil")
+	}
+	if env.GetPayload().GetTxID() == "" {
+		return nil, errors.New("transaction ID is empty")
+	}
+	if len(env.GetPayload().GetMustSignUserIDs()) == 0 {
+		return nil, errors.New("transaction must sign users list is empty")
+	}
+
+	// Signatures are added by co-signing, keep the caller's copy intact
+	loadedEnv := &types.DataTxEnvelope{
+		Payload:    env.GetPayload(),
+		Signatures: map[string][]byte{},
+	}
+	for userID, signature := range env.GetSignatures() {
+		loadedEnv.Signatures[userID] = signature
+	}
+	commonCtx.txID = loadedEnv.GetPayload().GetTxID()
+
+	return &loadedDataTxContext{
+		commonTxContext: commonCtx,
+		loadedEnvelope:  loadedEnv,
+	}, nil
+}
+
+func (d *loadedDataTxContext) Commit(sync bool) (string, *types.TxReceipt, error) {
+	return d.CommitCtx(context.Background(), sync)
+}
+
+func (d *loadedDataTxContext) CommitCtx(ctx context.Context, sync bool) (string, *types.TxReceipt, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.checkSignatures(); err != nil {
+		return "", nil, err
+	}
+	return d.commit(ctx, d, constants.PostDataTx, sync)
+}
+
+func (d *loadedDataTxContext) CommitFuture(ctx context.Context, sync bool) (TxFuture, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.checkSignatures(); err != nil {
+		return nil, err
+	}
+	return d.commitFuture(ctx, d, constants.PostDataTx, sync)
+}
+
+func (d *loadedDataTxContext) Abort() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.abort(d)
+}
+
+func (d *loadedDataTxContext) TxID() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.txID
+}
+
+// SetTxID ID of loaded transaction is fixed by the envelope signatures, therefore can't be changed
+func (d *loadedDataTxContext) SetTxID(txID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if txID == d.txID {
+		return nil
+	}
+	return errors.Errorf("ID of loaded transaction [%s] can't be changed", d.txID)
+}
+
+func (d *loadedDataTxContext) MustSignUsers() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]string{}, d.loadedEnvelope.GetPayload().GetMustSignUserIDs()...)
+}
+
+func (d *loadedDataTxContext) SignedUsers() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var users []string
+	for userID := range d.loadedEnvelope.GetSignatures() {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+	return users
+}
+
+func (d *loadedDataTxContext) Reads() map[string][]*types.DataRead {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	reads := map[string][]*types.DataRead{}
+	for _, op := range d.loadedEnvelope.GetPayload().GetDBOperations() {
+		reads[op.GetDBName()] = append(reads[op.GetDBName()], op.GetDataReads()...)
+	}
+	return reads
+}
+
+func (d *loadedDataTxContext) Writes() map[string][]*types.DataWrite {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	writes := map[string][]*types.DataWrite{}
+	for _, op := range d.loadedEnvelope.GetPayload().GetDBOperations() {
+		writes[op.GetDBName()] = append(writes[op.GetDBName()], op.GetDataWrites()...)
+	}
+	return writes
+}
+
+func (d *loadedDataTxContext) Deletes() map[string][]*types.DataDelete {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	deletes := map[string][]*types.DataDelete{}
+	for _, op := range d.loadedEnvelope.GetPayload().GetDBOperations() {
+		deletes[op.GetDBName()] = append(deletes[op.GetDBName()], op.GetDataDeletes()...)
+	}
+	return deletes
+}
+
+func (d *loadedDataTxContext) CoSignTxEnvelopeAndCloseTx() (proto.Message, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.txSpent {
+		return nil, ErrTxSpent
+	}
+
+	if !d.isMustSignUser() {
+		return nil, errors.Errorf("user [%s] is not in the must sign users list of transaction [%s]", d.userID, d.txID)
+	}
+
+	env, err := d.composeEnvelope(d.txID)
+	if err != nil {
+		d.logger.Errorf("failed to co-sign transaction envelope, due to %s", err)
+		return nil, err
+	}
+
+	d.txEnvelope = env
+	d.txSpent = true
+	return env, nil
+}
+
+// checkSignatures verifies that all must sign users, except the session user, signed the transaction
+func (d *loadedDataTxContext) checkSignatures() error {
+	if d.txSpent {
+		return nil
+	}
+
+	var missing []string
+	for _, userID := range d.loadedEnvelope.GetPayload().GetMustSignUserIDs() {
+		if _, signed := d.loadedEnvelope.GetSignatures()[userID]; !signed && userID != d.userID {
+			missing = append(missing, userID)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("transaction [%s] is missing signatures of users %v", d.txID, missing)
+	}
+	return nil
+}
+
+func (d *loadedDataTxContext) isMustSignUser() bool {
+	for _, userID := range d.loadedEnvelope.GetPayload().GetMustSignUserIDs() {
+		if userID == d.userID {
+			return true
+		}
+	}
+	return false
+}
+
+// composeEnvelope adds the session user signature, if user must sign the transaction
+// and didn't sign it yet
+func (d *loadedDataTxContext) composeEnvelope(txID string) (proto.Message, error) {
+	if _, signed := d.loadedEnvelope.GetSignatures()[d.userID]; signed || !d.isMustSignUser() {
+		return d.loadedEnvelope, nil
+	}
+
+	signature, err := cryptoservice.SignTx(d.signer, d.loadedEnvelope.GetPayload())
+	if err != nil {
+		return nil, err
+	}
+	d.loadedEnvelope.Signatures[d.userID] = signature
+	return d.loadedEnvelope, nil
+}
+
+func (d *loadedDataTxContext) cleanCtx() {}
@@ -0,0 +1,159 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/bcdb/mocks"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadedDataTxContext_MultiSign(t *testing.T) {
+	logger := createTestLogger(t)
+
+	newCommonCtx := func(userID string, signature []byte, httpClient *mockHttpClient) *commonTxContext {
+		signer := &mocks.Signer{}
+		signer.On("Sign", mock.Anything).Return(signature, nil)
+		return &commonTxContext{
+			userID:   userID,
+			signer:   signer,
+			userCert: []byte{1, 2, 3},
+			replicaSet: map[string]*url.URL{
+				"node1": {
+					Path: "http://localhost:8888",
+				},
+			},
+			nodesCerts:    testNodesCerts(),
+			restClient:    NewRestClient(userID, httpClient, signer),
+			commitTimeout: time.Second * 2,
+			logger:        logger,
+		}
+	}
+
+	var submitted *types.DataTxEnvelope
+	httpClient := &mockHttpClient{
+		process: func(req *http.Request, resp *http.Response) (*http.Response, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			submitted = &types.DataTxEnvelope{}
+			if err := json.Unmarshal(body, submitted); err != nil {
+				return nil, err
+			}
+			return syncSubmit(req, resp)
+		},
+		resp: okResponse(),
+	}
+
+	aliceTx := &dataTxContext{
+		commonTxContext: newCommonCtx("alice", []byte{1}, httpClient),
+		operations:      map[string]*dbOperations{},
+	}
+	require.NoError(t, aliceTx.Put("bdb", "key1", []byte("value1"), nil))
+	aliceTx.AddMustSignUser("bob")
+	aliceTx.AddMustSignUser("bob")
+	aliceTx.AddMustSignUser("alice")
+
+	msg, err := aliceTx.SignConstructedTxEnvelopeAndCloseTx()
+	require.NoError(t, err)
+	aliceEnv := msg.(*types.DataTxEnvelope)
+	require.Equal(t, []string{"alice", "bob"}, aliceEnv.GetPayload().GetMustSignUserIDs())
+	require.Equal(t, map[string][]byte{"alice": {1}}, aliceEnv.GetSignatures())
+	require.NotEmpty(t, aliceEnv.GetPayload().GetTxID())
+
+	_, err = aliceTx.SignConstructedTxEnvelopeAndCloseTx()
+	require.Equal(t, ErrTxSpent, err)
+	aliceTx.AddMustSignUser("charlie")
+	require.Empty(t, aliceTx.mustSignUsers)
+
+	// Commit by user not in must sign list fails, since bob didn't sign yet
+	charlieTx, err := newLoadedDataTxContext(newCommonCtx("charlie", []byte{3}, httpClient), aliceEnv)
+	require.NoError(t, err)
+	_, err = charlieTx.CoSignTxEnvelopeAndCloseTx()
+	require.EqualError(t, err, "user [charlie] is not in the must sign users list of transaction ["+aliceEnv.GetPayload().GetTxID()+"]")
+	_, _, err = charlieTx.Commit(true)
+	require.EqualError(t, err, "transaction ["+aliceEnv.GetPayload().GetTxID()+"] is missing signatures of users [bob]")
+	require.Nil(t, submitted)
+
+	bobTx, err := newLoadedDataTxContext(newCommonCtx("bob", []byte{2}, httpClient), aliceEnv)
+	require.NoError(t, err)
+	require.Equal(t, aliceEnv.GetPayload().GetTxID(), bobTx.TxID())
+	require.NoError(t, bobTx.SetTxID(aliceEnv.GetPayload().GetTxID()))
+	require.EqualError(t, bobTx.SetTxID("other"), "ID of loaded transaction ["+aliceEnv.GetPayload().GetTxID()+"] can't be changed")
+	require.Equal(t, []string{"alice", "bob"}, bobTx.MustSignUsers())
+	require.Equal(t, []string{"alice"}, bobTx.SignedUsers())
+	require.Len(t, bobTx.Writes()["bdb"], 1)
+	require.Equal(t, "key1", bobTx.Writes()["bdb"][0].GetKey())
+	require.Empty(t, bobTx.Reads()["bdb"])
+	require.Empty(t, bobTx.Deletes()["bdb"])
+
+	msg, err = bobTx.CoSignTxEnvelopeAndCloseTx()
+	require.NoError(t, err)
+	bobEnv := msg.(*types.DataTxEnvelope)
+	require.Equal(t, map[string][]byte{"alice": {1}, "bob": {2}}, bobEnv.GetSignatures())
+	// Loaded envelope is not modified by co-signing
+	require.Equal(t, map[string][]byte{"alice": {1}}, aliceEnv.GetSignatures())
+
+	charlieTx, err = newLoadedDataTxContext(newCommonCtx("charlie", []byte{3}, httpClient), bobEnv)
+	require.NoError(t, err)
+	txID, receipt, err := charlieTx.Commit(true)
+	require.NoError(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, bobEnv.GetPayload().GetTxID(), txID)
+	require.Equal(t, map[string][]byte{"alice": {1}, "bob": {2}}, submitted.GetSignatures())
+	require.Equal(t, []string{"alice", "bob"}, submitted.GetPayload().GetMustSignUserIDs())
+}
+
+func TestLoadedDataTxContext_BadEnvelope(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    *types.DataTxEnvelope
+		errMsg string
+	}{
+		{
+			name:   "nil envelope",
+			env:    nil,
+			errMsg: "transaction envelope or its payload is nil",
+		},
+		{
+			name:   "nil payload",
+			env:    &types.DataTxEnvelope{},
+			errMsg: "transaction envelope or its payload is nil",
+		},
+		{
+			name: "empty txID",
+			env: &types.DataTxEnvelope{
+				Payload: &types.DataTx{
+					MustSignUserIDs: []string{"alice"},
+				},
+			},
+			errMsg: "transaction ID is empty",
+		},
+		{
+			name: "empty must sign users",
+			env: &types.DataTxEnvelope{
+				Payload: &types.DataTx{
+					TxID: "tx1",
+				},
+			},
+			errMsg: "transaction must sign users list is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txCtx, err := newLoadedDataTxContext(&commonTxContext{userID: "bob"}, tt.env)
+			require.EqualError(t, err, tt.errMsg)
+			require.Nil(t, txCtx)
+		})
+	}
+}
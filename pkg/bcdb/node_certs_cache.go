@@ -0,0 +1,120 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"crypto/x509"
+	"sync"
+	"time"
+)
+
+const defaultNodeCertsCacheTTL = 5 * time.Minute
+
+// nodeCertsCache keeps certificates of the cluster nodes, verified with CA certificates, shared by
+// all transaction contexts of the session. Certificates are downloaded again once TTL expires or
+// after the cache was invalidated
+type nodeCertsCache struct {
+	ttl   time.Duration
+	fetch func(ctx context.Context) (map[string]*x509.Certificate, error)
+	now   func() time.Time
+
+	mu        sync.Mutex
+	certs     map[string]*x509.Certificate
+	fetchedAt time.Time
+	// download in progress, shared by concurrent callers
+	download *nodeCertsDownload
+}
+
+// nodeCertsDownload download of certificates, run by the caller which started it, other callers wait for
+// the outcome
+type nodeCertsDownload struct {
+	done  chan struct{}
+	certs map[string]*x509.Certificate
+	err   error
+	// abandoned the context of the caller, which run the download, was done
+	abandoned bool
+}
+
+func newNodeCertsCache(ttl time.Duration, fetch func(ctx context.Context) (map[string]*x509.Certificate, error)) *nodeCertsCache {
+	if ttl <= 0 {
+		ttl = defaultNodeCertsCacheTTL
+	}
+	return &nodeCertsCache{
+		ttl:   ttl,
+		fetch: fetch,
+		now:   time.Now,
+	}
+}
+
+// get returns cached certificates, downloads them if cache is empty, expired or invalidated
+func (c *nodeCertsCache) get(ctx context.Context) (map[string]*x509.Certificate, error) {
+	return c.load(ctx, false)
+}
+
+// refresh downloads certificates regardless of cache state, joins the download in progress if any
+func (c *nodeCertsCache) refresh(ctx context.Context) (map[string]*x509.Certificate, error) {
+	return c.load(ctx, true)
+}
+
+// load returns cached certificates or downloads them. Lock isn't held during the download, concurrent callers
+// wait for the download in progress until their own ctx is done, and retry it if it was abandoned by the caller
+// which run it
+func (c *nodeCertsCache) load(ctx context.Context, refresh bool) (map[string]*x509.Certificate, error) {
+	for {
+		c.mu.Lock()
+		if !refresh && c.certs != nil && c.now().Sub(c.fetchedAt) < c.ttl {
+			certs := c.certs
+			c.mu.Unlock()
+			return certs, nil
+		}
+		d := c.download
+		if d == nil {
+			d = &nodeCertsDownload{done: make(chan struct{})}
+			c.download = d
+			c.mu.Unlock()
+			c.run(ctx, d)
+			return d.certs, d.err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-d.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !d.abandoned || ctx.Err() != nil {
+			return d.certs, d.err
+		}
+	}
+}
+
+// run fetches certificates and stores them, unless cache was invalidated during the download
+func (c *nodeCertsCache) run(ctx context.Context, d *nodeCertsDownload) {
+	certs, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d.certs, d.err = certs, err
+	d.abandoned = err != nil && ctx.Err() != nil
+	close(d.done)
+	if c.download != d {
+		return
+	}
+	c.download = nil
+	if err == nil {
+		c.certs = certs
+		c.fetchedAt = c.now()
+	}
+}
+
+// invalidate drops cached certificates, next get downloads them from the server. Certificates of the download
+// in progress aren't cached
+func (c *nodeCertsCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.certs = nil
+	c.download = nil
+}
@@ -0,0 +1,322 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeCertsCache(t *testing.T) {
+	var fetches int32
+	fetchErr := errors.New("replicas are unreachable")
+	var failFetch bool
+	cache := newNodeCertsCache(time.Minute, func(ctx context.Context) (map[string]*x509.Certificate, error) {
+		if failFetch {
+			return nil, fetchErr
+		}
+		atomic.AddInt32(&fetches, 1)
+		return testNodesCerts(), nil
+	})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		certs, err := cache.get(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, testNodesCerts(), certs)
+	}
+	require.Equal(t, int32(1), fetches)
+
+	// TTL expired
+	now = now.Add(time.Minute)
+	_, err := cache.get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(2), fetches)
+
+	cache.invalidate()
+	_, err = cache.get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(3), fetches)
+
+	_, err = cache.refresh(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(4), fetches)
+
+	// failed fetch doesn't populate the cache
+	cache.invalidate()
+	failFetch = true
+	_, err = cache.get(context.Background())
+	require.Equal(t, fetchErr, err)
+	failFetch = false
+	_, err = cache.get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(5), fetches)
+
+	require.Equal(t, defaultNodeCertsCacheTTL, newNodeCertsCache(0, nil).ttl)
+}
+
+func TestNodeCertsCache_ConcurrentDownload(t *testing.T) {
+	var fetches int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	cache := newNodeCertsCache(time.Minute, func(ctx context.Context) (map[string]*x509.Certificate, error) {
+		atomic.AddInt32(&fetches, 1)
+		started <- struct{}{}
+		select {
+		case <-release:
+			return testNodesCerts(), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	type outcome struct {
+		certs map[string]*x509.Certificate
+		err   error
+	}
+	get := func(ctx context.Context) <-chan outcome {
+		res := make(chan outcome, 1)
+		go func() {
+			certs, err := cache.get(ctx)
+			res <- outcome{certs, err}
+		}()
+		return res
+	}
+
+	// waiter gives up once its own context is done, while download is still in progress
+	first := get(context.Background())
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	waiter := <-get(ctx)
+	require.Equal(t, context.DeadlineExceeded, waiter.err)
+
+	// concurrent callers share the download
+	second := get(context.Background())
+	close(release)
+	for _, res := range []<-chan outcome{first, second} {
+		o := <-res
+		require.NoError(t, o.err)
+		require.Equal(t, testNodesCerts(), o.certs)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	// download abandoned by the caller which started it is retried by the waiter
+	cache.invalidate()
+	release = make(chan struct{})
+	ctx, cancel = context.WithCancel(context.Background())
+	abandoned := get(ctx)
+	<-started
+	waiting := get(context.Background())
+	cancel()
+	require.Equal(t, context.Canceled, (<-abandoned).err)
+	<-started
+	close(release)
+	o := <-waiting
+	require.NoError(t, o.err)
+	require.Equal(t, testNodesCerts(), o.certs)
+	require.Equal(t, int32(3), atomic.LoadInt32(&fetches))
+}
+
+func TestSession_NodeCertsCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "CA")
+	caPath := writeTestPEM(t, dir, "CA.pem", certPEMBlock(ca.cert))
+	nodeCert, nodeKey := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "node1"}})
+	node := &testNode{id: "node1", cert: nodeCert, key: nodeKey}
+	// impostor signs data responses on behalf of node1
+	impostor := newTestNode("node1")
+
+	var configRequests int32
+	var badSignature int32
+	server := newSignedResponseServer(t, func(r *http.Request) (proto.Message, *testNode) {
+		switch r.URL.Path {
+		case constants.URLForGetConfig():
+			atomic.AddInt32(&configRequests, 1)
+			return testClusterConfigResponse(node), node
+		case constants.URLForGetData("bdb", "key"):
+			if atomic.LoadInt32(&badSignature) == 1 {
+				return &types.GetDataResponse{Value: []byte("value")}, impostor
+			}
+			return &types.GetDataResponse{Value: []byte("value")}, node
+		default:
+			return nil, nil
+		}
+	})
+	defer server.Close()
+
+	db, err := Create(&config.ConnectionConfig{
+		ReplicaSet: []*config.Replica{{ID: "node1", Endpoint: server.URL}},
+		RootCAs:    []string{caPath},
+		Logger:     createTestLogger(t),
+	})
+	require.NoError(t, err)
+	session, err := db.Session(testSessionConfig(t, ca))
+	require.NoError(t, err)
+
+	// cluster config is downloaded once and shared by all contexts of the session
+	for i := 0; i < 3; i++ {
+		tx, err := session.DataTx()
+		require.NoError(t, err)
+		value, _, err := tx.Get("bdb", "key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+		require.NoError(t, tx.Abort())
+	}
+	_, err = session.Ledger()
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&configRequests))
+
+	// signature verification failure invalidates the cache
+	atomic.StoreInt32(&badSignature, 1)
+	tx, err := session.DataTx()
+	require.NoError(t, err)
+	_, _, err = tx.Get("bdb", "key")
+	require.True(t, errors.Is(err, ErrBadSignature))
+	require.Equal(t, int32(1), atomic.LoadInt32(&configRequests))
+
+	atomic.StoreInt32(&badSignature, 0)
+	_, err = session.DataTx()
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&configRequests))
+
+	// committed config transaction invalidates the cache
+	configTx := &configTxContext{commonTxContext: &commonTxContext{nodesCertsCache: session.(*dbSession).nodesCerts}}
+	configTx.configCommitted(true, false)
+	_, err = session.DataTx()
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&configRequests))
+}
+
+func TestSession_ConfigCommitFuture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "CA")
+	caPath := writeTestPEM(t, dir, "CA.pem", certPEMBlock(ca.cert))
+	nodeCert, nodeKey := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "node1"}})
+	node := &testNode{id: "node1", cert: nodeCert, key: nodeKey}
+
+	var configRequests int32
+	var committed int32
+	server := newSignedResponseServer(t, func(r *http.Request) (proto.Message, *testNode) {
+		switch {
+		case r.URL.Path == constants.PostConfigTx && r.Method == http.MethodPost:
+			// transaction accepted, receipt isn't available yet
+			return &types.TxResponse{}, node
+		case r.URL.Path == constants.URLForGetConfig():
+			atomic.AddInt32(&configRequests, 1)
+			return testClusterConfigResponse(node), node
+		case strings.HasPrefix(r.URL.Path, constants.URLForGetTransactionReceipt("")):
+			if atomic.LoadInt32(&committed) == 0 {
+				return nil, nil
+			}
+			return &types.TxResponse{Receipt: receiptWithFlag(types.Flag_VALID, "")}, node
+		default:
+			return nil, nil
+		}
+	})
+	defer server.Close()
+
+	db, err := Create(&config.ConnectionConfig{
+		ReplicaSet: []*config.Replica{{ID: "node1", Endpoint: server.URL}},
+		RootCAs:    []string{caPath},
+		Logger:     createTestLogger(t),
+	})
+	require.NoError(t, err)
+	sessionConfig := testSessionConfig(t, ca)
+	sessionConfig.ReceiptPolling = &config.ReceiptPollingConfig{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+	session, err := db.Session(sessionConfig)
+	require.NoError(t, err)
+
+	configTx, err := session.ConfigTx()
+	require.NoError(t, err)
+	_, err = session.DataTx()
+	require.NoError(t, err)
+	requests := atomic.LoadInt32(&configRequests)
+
+	f, err := configTx.CommitFuture(context.Background(), false)
+	require.NoError(t, err)
+	require.NotNil(t, f)
+
+	// cached node certificates are kept until the receipt of config transaction is known
+	_, err = session.DataTx()
+	require.NoError(t, err)
+	require.Equal(t, requests, atomic.LoadInt32(&configRequests))
+
+	atomic.StoreInt32(&committed, 1)
+	_, err = f.Wait(context.Background())
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, err = session.DataTx()
+		return err == nil && atomic.LoadInt32(&configRequests) > requests
+	}, time.Second, 5*time.Millisecond)
+}
+
+// newSignedResponseServer starts server, which responds with the message returned by respond, signed by
+// the returned node. Request is rejected with bad request status if respond returns nil
+func newSignedResponseServer(t *testing.T, respond func(r *http.Request) (proto.Message, *testNode)) *httptest.Server {
+	return httptest.NewServer(signedResponseHandler(t, respond))
+}
+
+// signedResponseHandler responds with the message returned by respond, see newSignedResponseServer
+func signedResponseHandler(t *testing.T, respond func(r *http.Request) (proto.Message, *testNode)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, signer := respond(r)
+		if res == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resEnv := &types.ResponseEnvelope{
+			Payload: MarshalOrPanic(&types.Payload{
+				Header:   &types.ResponseHeader{NodeID: signer.id},
+				Response: MarshalOrPanic(res),
+			}),
+		}
+		resEnv.Signature = signer.sign(resEnv.Payload)
+		require.NoError(t, json.NewEncoder(w).Encode(resEnv))
+	})
+}
+
+// testClusterConfigResponse returns config response of the cluster with the single node
+func testClusterConfigResponse(node *testNode) *types.GetConfigResponse {
+	return &types.GetConfigResponse{Config: &types.ClusterConfig{
+		Nodes: []*types.NodeConfig{{ID: node.id, Certificate: node.cert.Raw}},
+	}}
+}
+
+// testSessionConfig returns session config of user alice, which certificate is issued by ca
+func testSessionConfig(t *testing.T, ca *testCA) *config.SessionConfig {
+	userCert, userKey := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}})
+	return &config.SessionConfig{
+		UserConfig: &config.UserConfig{
+			UserID:     "alice",
+			Cert:       pem.EncodeToMemory(certPEMBlock(userCert)),
+			PrivateKey: pem.EncodeToMemory(keyPEMBlock(t, userKey)),
+		},
+		QueryTimeout: time.Second,
+	}
+}
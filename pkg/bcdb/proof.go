@@ -16,13 +16,20 @@ type TxProof struct {
 	intermediateHashes [][]byte
 }
 
+// Verify checks that the transaction envelope, along with its validation info, is part of the block
+// referenced by the receipt. Supports all transaction envelopes returned by TxContext.TxEnvelope(),
+// i.e. data, user administration, database administration and config transactions
 func (p *TxProof) Verify(receipt *types.TxReceipt, tx proto.Message) (bool, error) {
-	txEnv, ok := tx.(*types.DataTxEnvelope)
-	if !ok {
-		return false, errors.Errorf("tx [%s] is not data transaction, only data transaction supported so far", tx.String())
+	switch tx.(type) {
+	case *types.DataTxEnvelope, *types.UserAdministrationTxEnvelope, *types.DBAdministrationTxEnvelope, *types.ConfigTxEnvelope:
+	default:
+		return false, errors.Errorf("tx [%s] has unsupported transaction envelope type %T", tx.String(), tx)
+	}
+	if receipt.GetTxIndex() >= uint64(len(receipt.GetHeader().GetValidationInfo())) {
+		return false, errors.Errorf("receipt has no validation info for tx index %d", receipt.GetTxIndex())
 	}
 	valInfo := receipt.GetHeader().GetValidationInfo()[receipt.GetTxIndex()]
-	txBytes, err := json.Marshal(txEnv)
+	txBytes, err := json.Marshal(tx)
 	if err != nil {
 		return false, errors.Wrapf(err, "can't serialize tx [%s] to json", tx.String())
 	}
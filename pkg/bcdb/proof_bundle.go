@@ -0,0 +1,278 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// ProofBundleVersion the version of the proof bundle format produced by ExportProofBundle
+const ProofBundleVersion = 1
+
+// ProofBundle self-contained evidence of the transaction, which can be verified by VerifyProofBundle
+// without access to the database. Bundle serialized with encoding/json, binary fields encoded as base64.
+//
+// The tx proof and the ledger path are kept as response envelopes signed by the nodes, so the bundle
+// can't be altered without invalidating the node signatures. The bundled node certificates are trusted
+// only if they pass the checks of ProofBundleTrust.
+type ProofBundle struct {
+	// Version of the bundle format, see ProofBundleVersion
+	Version int `json:"version"`
+	// Exactly one of the transaction envelopes is set
+	DataTxEnvelope               *types.DataTxEnvelope               `json:"dataTxEnvelope,omitempty"`
+	UserAdministrationTxEnvelope *types.UserAdministrationTxEnvelope `json:"userAdministrationTxEnvelope,omitempty"`
+	DBAdministrationTxEnvelope   *types.DBAdministrationTxEnvelope   `json:"dbAdministrationTxEnvelope,omitempty"`
+	ConfigTxEnvelope             *types.ConfigTxEnvelope             `json:"configTxEnvelope,omitempty"`
+	// Receipt of the transaction
+	Receipt *types.TxReceipt `json:"receipt"`
+	// TxProofResponse signed response to the tx proof query, carries types.GetTxProofResponse
+	TxProofResponse *types.ResponseEnvelope `json:"txProofResponse"`
+	// LedgerPathResponse signed response to the ledger path query from the genesis block to the
+	// receipt block, carries types.GetLedgerPathResponse
+	LedgerPathResponse *types.ResponseEnvelope `json:"ledgerPathResponse"`
+	// NodesCertificates DER encoded certificates of the nodes, by node ID
+	NodesCertificates map[string][]byte `json:"nodesCertificates"`
+}
+
+// TxEnvelope returns the transaction envelope packed into the bundle
+func (b *ProofBundle) TxEnvelope() (proto.Message, error) {
+	var envelopes []proto.Message
+	if b.DataTxEnvelope != nil {
+		envelopes = append(envelopes, b.DataTxEnvelope)
+	}
+	if b.UserAdministrationTxEnvelope != nil {
+		envelopes = append(envelopes, b.UserAdministrationTxEnvelope)
+	}
+	if b.DBAdministrationTxEnvelope != nil {
+		envelopes = append(envelopes, b.DBAdministrationTxEnvelope)
+	}
+	if b.ConfigTxEnvelope != nil {
+		envelopes = append(envelopes, b.ConfigTxEnvelope)
+	}
+	if len(envelopes) != 1 {
+		return nil, errors.Errorf("proof bundle should contain exactly one transaction envelope, found %d", len(envelopes))
+	}
+	return envelopes[0], nil
+}
+
+// ExportProofBundle collects the tx proof, the ledger path from the genesis block to the receipt block and the
+// nodes certificates and packs them, along with transaction envelope and receipt, into the proof bundle.
+// l should be the Ledger returned by DBSession.Ledger()
+func ExportProofBundle(ctx context.Context, l Ledger, envelope proto.Message, receipt *types.TxReceipt) (*ProofBundle, error) {
+	sdkLedger, ok := l.(*ledger)
+	if !ok {
+		return nil, errors.Errorf("unsupported ledger implementation %T", l)
+	}
+	if receipt.GetHeader() == nil {
+		return nil, errors.New("receipt has no block header")
+	}
+
+	bundle := &ProofBundle{
+		Version:           ProofBundleVersion,
+		Receipt:           receipt,
+		NodesCertificates: map[string][]byte{},
+	}
+	switch env := envelope.(type) {
+	case *types.DataTxEnvelope:
+		bundle.DataTxEnvelope = env
+	case *types.UserAdministrationTxEnvelope:
+		bundle.UserAdministrationTxEnvelope = env
+	case *types.DBAdministrationTxEnvelope:
+		bundle.DBAdministrationTxEnvelope = env
+	case *types.ConfigTxEnvelope:
+		bundle.ConfigTxEnvelope = env
+	default:
+		_, err := envelopeTxID(envelope)
+		return nil, err
+	}
+
+	blockNum := receipt.GetHeader().GetBaseHeader().GetNumber()
+	txIndex := int(receipt.GetTxIndex())
+	var err error
+	bundle.TxProofResponse, err = sdkLedger.handleSignedRequest(ctx, constants.URLTxProof(blockNum, txIndex), &types.GetTxProofQuery{
+		UserID:      sdkLedger.userID,
+		BlockNumber: blockNum,
+		TxIndex:     uint64(txIndex),
+	}, &types.GetTxProofResponse{})
+	if err != nil {
+		sdkLedger.logger.Errorf("failed to fetch transaction proof of block %d, tx %d, due to %s", blockNum, txIndex, err)
+		return nil, err
+	}
+
+	bundle.LedgerPathResponse, err = sdkLedger.handleSignedRequest(ctx, constants.URLForLedgerPath(1, blockNum), &types.GetLedgerPathQuery{
+		UserID:           sdkLedger.userID,
+		StartBlockNumber: 1,
+		EndBlockNumber:   blockNum,
+	}, &types.GetLedgerPathResponse{})
+	if err != nil {
+		sdkLedger.logger.Errorf("failed to fetch ledger path from block 1 to block %d, due to %s", blockNum, err)
+		return nil, err
+	}
+
+	for nodeID, cert := range sdkLedger.nodesCerts {
+		bundle.NodesCertificates[nodeID] = cert.Raw
+	}
+	return bundle, nil
+}
+
+// ProofBundleTrust trust anchors of VerifyProofBundle. Each node certificate carried by the bundle should either
+// be equal to the pinned certificate of the node or be issued by one of RootCAs. At least one of RootCAs and
+// NodesCertificates should be set
+type ProofBundleTrust struct {
+	// RootCAs CA certificates the node certificates should chain to
+	RootCAs *x509.CertPool
+	// IntermediateCAs optional CA certificates used to build the chain to one of RootCAs
+	IntermediateCAs *x509.CertPool
+	// NodesCertificates pinned certificates of the nodes, by node ID
+	NodesCertificates map[string]*x509.Certificate
+	// TrustedBlockHash hash of the trusted block header, e.g. of the genesis block or of the ledger checkpoint.
+	// The ledger path should contain the header, and is verified starting from it. If empty, the ledger path is
+	// verified only against the genesis block carried by the bundle and the report is marked Unanchored
+	TrustedBlockHash []byte
+}
+
+// VerifyProofBundle verifies the proof bundle offline: the bundled node certificates against trust anchors, the node
+// signatures over the tx proof and the ledger path, inclusion of the transaction into the receipt block, presence of
+// the receipt block in the ledger path from the trusted block and the validation flag of the transaction. Failed
+// checks of the transaction evidence are reported in TxVerificationReport, error returned if the bundle is malformed,
+// can't be anchored to trust or its signatures are not valid
+func VerifyProofBundle(bundle *ProofBundle, trust *ProofBundleTrust) (*TxVerificationReport, error) {
+	if bundle == nil {
+		return nil, errors.New("proof bundle is nil")
+	}
+	if trust == nil || (trust.RootCAs == nil && len(trust.NodesCertificates) == 0) {
+		return nil, errors.New("proof bundle trust should have root CA certificates or pinned node certificates")
+	}
+	if bundle.Version != ProofBundleVersion {
+		return nil, errors.Errorf("unsupported proof bundle version %d", bundle.Version)
+	}
+	envelope, err := bundle.TxEnvelope()
+	if err != nil {
+		return nil, err
+	}
+
+	nodesCerts := map[string]*x509.Certificate{}
+	for nodeID, certBytes := range bundle.NodesCertificates {
+		cert, err := x509.ParseCertificate(certBytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse certificate of node [%s]", nodeID)
+		}
+		if err = trust.verifyNodeCert(nodeID, cert); err != nil {
+			return nil, err
+		}
+		nodesCerts[nodeID] = cert
+	}
+
+	proofRes := &types.GetTxProofResponse{}
+	if err = openSignedResponse(nodesCerts, bundle.TxProofResponse, proofRes); err != nil {
+		return nil, errors.WithMessage(err, "failed to open tx proof response")
+	}
+	pathRes := &types.GetLedgerPathResponse{}
+	if err = openSignedResponse(nodesCerts, bundle.LedgerPathResponse, pathRes); err != nil {
+		return nil, errors.WithMessage(err, "failed to open ledger path response")
+	}
+
+	source := &bundleEvidenceSource{
+		proof: &TxProof{intermediateHashes: proofRes.GetHashes()},
+		path:  pathRes.GetBlockHeaders(),
+	}
+	// Without the trusted block the genesis block of the bundle is used, which isn't a trust anchor
+	var trusted *types.BlockHeader
+	if len(trust.TrustedBlockHash) > 0 {
+		if trusted, err = source.trustedHeader(trust.TrustedBlockHash); err != nil {
+			return nil, err
+		}
+	}
+	return verifyTx(context.Background(), source, nil, trusted, envelope, bundle.Receipt)
+}
+
+// verifyNodeCert checks that the node certificate is pinned or is issued by one of the trusted CAs
+func (t *ProofBundleTrust) verifyNodeCert(nodeID string, cert *x509.Certificate) error {
+	if pinned, ok := t.NodesCertificates[nodeID]; ok {
+		if !bytes.Equal(pinned.Raw, cert.Raw) {
+			return errors.Errorf("certificate of node [%s] differs from the pinned certificate", nodeID)
+		}
+		return nil
+	}
+	if t.RootCAs == nil {
+		return errors.Errorf("certificate of node [%s] isn't pinned", nodeID)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         t.RootCAs,
+		Intermediates: t.IntermediateCAs,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to verify certificate of node [%s]", nodeID)
+	}
+	return nil
+}
+
+// openSignedResponse verifies the node signature over the response envelope and unmarshals the response
+func openSignedResponse(nodesCerts map[string]*x509.Certificate, resEnv *types.ResponseEnvelope, res proto.Message) error {
+	if resEnv == nil {
+		return errors.New("response is missing")
+	}
+	payload := &types.Payload{}
+	if err := json.Unmarshal(resEnv.GetPayload(), payload); err != nil {
+		return errors.Wrap(err, "failed to unmarshal response payload")
+	}
+	if err := verifyResponseSignature(nodesCerts, resEnv, payload); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(payload.GetResponse(), res); err != nil {
+		return errors.Wrap(err, "failed to unmarshal response")
+	}
+	return nil
+}
+
+// bundleEvidenceSource serves the tx proof and the ledger path, packed into the proof bundle
+type bundleEvidenceSource struct {
+	proof *TxProof
+	path  []*types.BlockHeader
+}
+
+func (s *bundleEvidenceSource) getTransactionProof(_ context.Context, _ uint64, _ int) (*TxProof, error) {
+	return s.proof, nil
+}
+
+func (s *bundleEvidenceSource) getBlockHeader(_ context.Context, blockNum uint64) (*types.BlockHeader, error) {
+	if len(s.path) > 0 {
+		if start := s.path[len(s.path)-1]; start.GetBaseHeader().GetNumber() == blockNum {
+			return start, nil
+		}
+	}
+	return nil, errors.Errorf("proof bundle has no header of block %d", blockNum)
+}
+
+// getLedgerPath returns the part of the bundled ledger path, which ends at the start block
+func (s *bundleEvidenceSource) getLedgerPath(_ context.Context, startBlock, endBlock uint64) ([]*types.BlockHeader, error) {
+	if len(s.path) > 0 && s.path[0].GetBaseHeader().GetNumber() == endBlock {
+		for i, header := range s.path {
+			if header.GetBaseHeader().GetNumber() == startBlock {
+				return s.path[:i+1], nil
+			}
+		}
+	}
+	return nil, errors.Errorf("proof bundle has no ledger path from block %d to block %d", startBlock, endBlock)
+}
+
+// trustedHeader returns the header of the ledger path with the trusted hash
+func (s *bundleEvidenceSource) trustedHeader(trustedHash []byte) (*types.BlockHeader, error) {
+	for _, header := range s.path {
+		hash, err := ComputeBlockHeaderHash(header)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(hash, trustedHash) {
+			return header, nil
+		}
+	}
+	return nil, errors.New("proof bundle ledger path doesn't contain the trusted block header")
+}
@@ -0,0 +1,240 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/bcdb/mocks"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func signedResponse(node *testNode, res proto.Message) *http.Response {
+	resEnv := &types.ResponseEnvelope{
+		Payload: MarshalOrPanic(&types.Payload{
+			Header: &types.ResponseHeader{
+				NodeID: node.id,
+			},
+			Response: MarshalOrPanic(res),
+		}),
+	}
+	resEnv.Signature = node.sign(resEnv.Payload)
+	resJson, _ := json.Marshal(resEnv)
+	return &http.Response{
+		StatusCode: 200,
+		Status:     http.StatusText(200),
+		Body:       ioutil.NopCloser(bytes.NewReader(resJson)),
+	}
+}
+
+func TestProofBundle(t *testing.T) {
+	tx := &types.DataTxEnvelope{
+		Payload: &types.DataTx{
+			MustSignUserIDs: []string{"alice"},
+			TxID:            "tx1",
+		},
+		Signatures: map[string][]byte{"alice": {1}},
+	}
+	chain := testLedgerChain(t, 5)
+	chain, proof := testAppendTxBlock(t, chain, tx, types.Flag_VALID)
+	chain = testExtendLedgerChain(t, chain, 10, 0)
+	receipt := &types.TxReceipt{
+		Header:  chain[5],
+		TxIndex: 0,
+	}
+	path := testLedgerPath(chain, 1, 6)
+
+	ca := newTestCA(t, "ca")
+	nodeCert, nodeKey := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "node1"}})
+	node := &testNode{id: "node1", cert: nodeCert, key: nodeKey}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	genesisHash, err := ComputeBlockHeaderHash(chain[0])
+	require.NoError(t, err)
+	trust := &ProofBundleTrust{RootCAs: roots, TrustedBlockHash: genesisHash}
+
+	signer := &mocks.Signer{}
+	signer.On("Sign", mock.Anything).Return([]byte{1}, nil)
+	httpClient := &mockHttpClient{
+		process: func(req *http.Request, resp *http.Response) (*http.Response, error) {
+			switch {
+			case strings.HasPrefix(req.URL.Path, "/ledger/proof/6"):
+				return signedResponse(node, &types.GetTxProofResponse{
+					Hashes: proof.intermediateHashes,
+				}), nil
+			case req.URL.Path == "/ledger/path" && req.URL.Query().Get("start") == "1" && req.URL.Query().Get("end") == "6":
+				return signedResponse(node, &types.GetLedgerPathResponse{
+					BlockHeaders: path,
+				}), nil
+			default:
+				return serverBadRequestResponse(), nil
+			}
+		},
+	}
+	l := &ledger{
+		commonTxContext: &commonTxContext{
+			userID: "alice",
+			signer: signer,
+			replicaSet: map[string]*url.URL{
+				"node1": {
+					Path: "http://localhost:8888",
+				},
+			},
+			nodesCerts: map[string]*x509.Certificate{node.id: node.cert},
+			restClient: NewRestClient("alice", httpClient, signer),
+			logger:     createTestLogger(t),
+		},
+	}
+
+	bundle, err := ExportProofBundle(context.Background(), l, tx, receipt)
+	require.NoError(t, err)
+	bundleBytes, err := json.Marshal(bundle)
+	require.NoError(t, err)
+
+	loadBundle := func() *ProofBundle {
+		loaded := &ProofBundle{}
+		require.NoError(t, json.Unmarshal(bundleBytes, loaded))
+		return loaded
+	}
+
+	t.Run("verified against root CA", func(t *testing.T) {
+		report, err := VerifyProofBundle(loadBundle(), trust)
+		require.NoError(t, err)
+		require.True(t, report.Verified())
+		require.False(t, report.Unanchored)
+		require.True(t, report.TxIncluded)
+		require.True(t, report.HeaderInLedger)
+		require.True(t, report.Valid)
+		require.Equal(t, "tx1", report.TxID)
+		require.Equal(t, uint64(6), report.BlockNumber)
+		require.Equal(t, uint64(1), report.TrustedBlockNumber)
+	})
+
+	t.Run("no trusted block, unanchored", func(t *testing.T) {
+		report, err := VerifyProofBundle(loadBundle(), &ProofBundleTrust{RootCAs: roots})
+		require.NoError(t, err)
+		require.False(t, report.Verified())
+		require.True(t, report.Unanchored)
+		require.True(t, report.TxIncluded)
+		require.False(t, report.HeaderInLedger)
+		require.True(t, report.Valid)
+		require.Equal(t, uint64(1), report.TrustedBlockNumber)
+		require.Equal(t, []string{"ledger path isn't anchored to trusted block header or ledger checkpoint"}, report.Failures)
+	})
+
+	t.Run("verified against pinned certificate and trusted block", func(t *testing.T) {
+		trustedHash, err := ComputeBlockHeaderHash(path[1])
+		require.NoError(t, err)
+		report, err := VerifyProofBundle(loadBundle(), &ProofBundleTrust{
+			NodesCertificates: map[string]*x509.Certificate{node.id: node.cert},
+			TrustedBlockHash:  trustedHash,
+		})
+		require.NoError(t, err)
+		require.True(t, report.Verified())
+		require.Equal(t, path[1].GetBaseHeader().GetNumber(), report.TrustedBlockNumber)
+	})
+
+	t.Run("no trust anchors", func(t *testing.T) {
+		report, err := VerifyProofBundle(loadBundle(), nil)
+		require.EqualError(t, err, "proof bundle trust should have root CA certificates or pinned node certificates")
+		require.Nil(t, report)
+
+		_, err = VerifyProofBundle(loadBundle(), &ProofBundleTrust{})
+		require.EqualError(t, err, "proof bundle trust should have root CA certificates or pinned node certificates")
+	})
+
+	t.Run("untrusted node certificate", func(t *testing.T) {
+		otherRoots := x509.NewCertPool()
+		otherRoots.AddCert(newTestCA(t, "other").cert)
+		report, err := VerifyProofBundle(loadBundle(), &ProofBundleTrust{RootCAs: otherRoots})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to verify certificate of node [node1]")
+		require.Nil(t, report)
+
+		_, err = VerifyProofBundle(loadBundle(), &ProofBundleTrust{
+			RootCAs:           roots,
+			NodesCertificates: testNodesCerts(),
+		})
+		require.EqualError(t, err, "certificate of node [node1] differs from the pinned certificate")
+
+		_, err = VerifyProofBundle(loadBundle(), &ProofBundleTrust{
+			NodesCertificates: map[string]*x509.Certificate{"node2": node.cert},
+		})
+		require.EqualError(t, err, "certificate of node [node1] isn't pinned")
+	})
+
+	t.Run("trusted block not in ledger path", func(t *testing.T) {
+		trustedHash, err := ComputeBlockHeaderHash(chain[8])
+		require.NoError(t, err)
+		report, err := VerifyProofBundle(loadBundle(), &ProofBundleTrust{
+			RootCAs:          roots,
+			TrustedBlockHash: trustedHash,
+		})
+		require.EqualError(t, err, "proof bundle ledger path doesn't contain the trusted block header")
+		require.Nil(t, report)
+	})
+
+	t.Run("tampered receipt", func(t *testing.T) {
+		tampered := loadBundle()
+		tampered.Receipt.Header.ValidationInfo[0] = &types.ValidationInfo{
+			Flag: types.Flag_INVALID_NO_PERMISSION,
+		}
+		report, err := VerifyProofBundle(tampered, trust)
+		require.NoError(t, err)
+		require.False(t, report.Verified())
+		require.False(t, report.TxIncluded)
+		require.False(t, report.HeaderInLedger)
+		require.False(t, report.Valid)
+	})
+
+	t.Run("tampered signed response", func(t *testing.T) {
+		tampered := loadBundle()
+		tampered.LedgerPathResponse.Signature = []byte{1, 2, 3}
+		report, err := VerifyProofBundle(tampered, trust)
+		require.True(t, errors.Is(err, ErrBadSignature))
+		require.Nil(t, report)
+	})
+
+	t.Run("missing node certificate", func(t *testing.T) {
+		tampered := loadBundle()
+		tampered.NodesCertificates = nil
+		report, err := VerifyProofBundle(tampered, trust)
+		require.True(t, errors.Is(err, ErrUnknownNode))
+		require.Nil(t, report)
+	})
+
+	t.Run("malformed bundle", func(t *testing.T) {
+		tampered := loadBundle()
+		tampered.Version = 2
+		_, err := VerifyProofBundle(tampered, trust)
+		require.EqualError(t, err, "unsupported proof bundle version 2")
+
+		tampered = loadBundle()
+		tampered.ConfigTxEnvelope = &types.ConfigTxEnvelope{}
+		_, err = VerifyProofBundle(tampered, trust)
+		require.EqualError(t, err, "proof bundle should contain exactly one transaction envelope, found 2")
+	})
+
+	t.Run("export failure", func(t *testing.T) {
+		_, err := ExportProofBundle(context.Background(), l, tx, &types.TxReceipt{
+			Header:  chain[8],
+			TxIndex: 0,
+		})
+		serverErr := &ServerError{}
+		require.True(t, errors.As(err, &serverErr))
+		require.Equal(t, http.StatusBadRequest, serverErr.StatusCode)
+	})
+}
@@ -0,0 +1,165 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/crypto"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxProof_Verify(t *testing.T) {
+	valInfo := &types.ValidationInfo{
+		Flag: types.Flag_VALID,
+	}
+
+	txHash := func(tx proto.Message) []byte {
+		txBytes, err := json.Marshal(tx)
+		require.NoError(t, err)
+		viBytes, err := json.Marshal(valInfo)
+		require.NoError(t, err)
+		hash, err := crypto.ComputeSHA256Hash(append(txBytes, viBytes...))
+		require.NoError(t, err)
+		return hash
+	}
+
+	tests := []struct {
+		name     string
+		tx       proto.Message
+		tampered proto.Message
+	}{
+		{
+			name: "data tx",
+			tx: &types.DataTxEnvelope{
+				Payload: &types.DataTx{
+					MustSignUserIDs: []string{"alice"},
+					TxID:            "tx1",
+				},
+				Signatures: map[string][]byte{"alice": {1}},
+			},
+			tampered: &types.DataTxEnvelope{
+				Payload: &types.DataTx{
+					MustSignUserIDs: []string{"alice"},
+					TxID:            "tx2",
+				},
+				Signatures: map[string][]byte{"alice": {1}},
+			},
+		},
+		{
+			name: "user administration tx",
+			tx: &types.UserAdministrationTxEnvelope{
+				Payload: &types.UserAdministrationTx{
+					UserID: "admin",
+					TxID:   "tx1",
+					UserWrites: []*types.UserWrite{
+						{
+							User: &types.User{ID: "alice"},
+						},
+					},
+				},
+				Signature: []byte{1},
+			},
+			tampered: &types.UserAdministrationTxEnvelope{
+				Payload: &types.UserAdministrationTx{
+					UserID: "admin",
+					TxID:   "tx1",
+					UserWrites: []*types.UserWrite{
+						{
+							User: &types.User{ID: "bob"},
+						},
+					},
+				},
+				Signature: []byte{1},
+			},
+		},
+		{
+			name: "db administration tx",
+			tx: &types.DBAdministrationTxEnvelope{
+				Payload: &types.DBAdministrationTx{
+					UserID:    "admin",
+					TxID:      "tx1",
+					CreateDBs: []string{"db1"},
+				},
+				Signature: []byte{1},
+			},
+			tampered: &types.DBAdministrationTxEnvelope{
+				Payload: &types.DBAdministrationTx{
+					UserID:    "admin",
+					TxID:      "tx1",
+					DeleteDBs: []string{"db1"},
+				},
+				Signature: []byte{1},
+			},
+		},
+		{
+			name: "config tx",
+			tx: &types.ConfigTxEnvelope{
+				Payload: &types.ConfigTx{
+					UserID: "admin",
+					TxID:   "tx1",
+					ReadOldConfigVersion: &types.Version{
+						BlockNum: 1,
+					},
+				},
+				Signature: []byte{1},
+			},
+			tampered: &types.ConfigTxEnvelope{
+				Payload: &types.ConfigTx{
+					UserID: "admin",
+					TxID:   "tx1",
+					ReadOldConfigVersion: &types.Version{
+						BlockNum: 2,
+					},
+				},
+				Signature: []byte{1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leafHash := txHash(tt.tx)
+			siblingHash, err := crypto.ComputeSHA256Hash([]byte("sibling"))
+			require.NoError(t, err)
+			rootHash, err := crypto.ConcatenateHashes(leafHash, siblingHash)
+			require.NoError(t, err)
+
+			receipt := &types.TxReceipt{
+				Header: &types.BlockHeader{
+					BaseHeader: &types.BlockHeaderBase{
+						Number: 2,
+					},
+					TxMerkelTreeRootHash: rootHash,
+					ValidationInfo:       []*types.ValidationInfo{valInfo},
+				},
+				TxIndex: 0,
+			}
+			proof := &TxProof{intermediateHashes: [][]byte{leafHash, siblingHash}}
+
+			res, err := proof.Verify(receipt, tt.tx)
+			require.NoError(t, err)
+			require.True(t, res)
+
+			res, err = proof.Verify(receipt, tt.tampered)
+			require.NoError(t, err)
+			require.False(t, res)
+		})
+	}
+}
+
+func TestTxProof_VerifyErrors(t *testing.T) {
+	proof := &TxProof{intermediateHashes: [][]byte{{1}}}
+
+	res, err := proof.Verify(&types.TxReceipt{}, &types.DataTx{TxID: "tx1"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "has unsupported transaction envelope type *types.DataTx")
+	require.False(t, res)
+
+	res, err = proof.Verify(&types.TxReceipt{TxIndex: 1}, &types.DataTxEnvelope{})
+	require.EqualError(t, err, "receipt has no validation info for tx index 1")
+	require.False(t, res)
+}
@@ -3,6 +3,8 @@
 package bcdb
 
 import (
+	"context"
+
 	"errors"
 
 	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
@@ -14,9 +16,13 @@ type provenance struct {
 }
 
 func (p *provenance) GetHistoricalData(dbName, key string) ([]*types.ValueWithMetadata, error) {
+	return p.GetHistoricalDataCtx(context.Background(), dbName, key)
+}
+
+func (p *provenance) GetHistoricalDataCtx(ctx context.Context, dbName, key string) ([]*types.ValueWithMetadata, error) {
 	path := constants.URLForGetHistoricalData(dbName, key)
 	res := &types.GetHistoricalDataResponse{}
-	err := p.handleRequest(path, &types.GetHistoricalDataQuery{
+	err := p.handleRequest(ctx, path, &types.GetHistoricalDataQuery{
 		UserID: p.userID,
 		DBName: dbName,
 		Key:    key,
@@ -29,9 +35,13 @@ func (p *provenance) GetHistoricalData(dbName, key string) ([]*types.ValueWithMe
 }
 
 func (p *provenance) GetHistoricalDataAt(dbName, key string, version *types.Version) (*types.ValueWithMetadata, error) {
+	return p.GetHistoricalDataAtCtx(context.Background(), dbName, key, version)
+}
+
+func (p *provenance) GetHistoricalDataAtCtx(ctx context.Context, dbName, key string, version *types.Version) (*types.ValueWithMetadata, error) {
 	path := constants.URLForGetHistoricalDataAt(dbName, key, version)
 	res := &types.GetHistoricalDataResponse{}
-	err := p.handleRequest(path, &types.GetHistoricalDataQuery{
+	err := p.handleRequest(ctx, path, &types.GetHistoricalDataQuery{
 		UserID:  p.userID,
 		DBName:  dbName,
 		Key:     key,
@@ -53,9 +63,13 @@ func (p *provenance) GetHistoricalDataAt(dbName, key string, version *types.Vers
 }
 
 func (p *provenance) GetPreviousHistoricalData(dbName, key string, version *types.Version) ([]*types.ValueWithMetadata, error) {
+	return p.GetPreviousHistoricalDataCtx(context.Background(), dbName, key, version)
+}
+
+func (p *provenance) GetPreviousHistoricalDataCtx(ctx context.Context, dbName, key string, version *types.Version) ([]*types.ValueWithMetadata, error) {
 	path := constants.URLForGetPreviousHistoricalData(dbName, key, version)
 	res := &types.GetHistoricalDataResponse{}
-	err := p.handleRequest(path, &types.GetHistoricalDataQuery{
+	err := p.handleRequest(ctx, path, &types.GetHistoricalDataQuery{
 		UserID:    p.userID,
 		DBName:    dbName,
 		Key:       key,
@@ -70,9 +84,13 @@ func (p *provenance) GetPreviousHistoricalData(dbName, key string, version *type
 }
 
 func (p *provenance) GetNextHistoricalData(dbName, key string, version *types.Version) ([]*types.ValueWithMetadata, error) {
+	return p.GetNextHistoricalDataCtx(context.Background(), dbName, key, version)
+}
+
+func (p *provenance) GetNextHistoricalDataCtx(ctx context.Context, dbName, key string, version *types.Version) ([]*types.ValueWithMetadata, error) {
 	path := constants.URLForGetNextHistoricalData(dbName, key, version)
 	res := &types.GetHistoricalDataResponse{}
-	err := p.handleRequest(path, &types.GetHistoricalDataQuery{
+	err := p.handleRequest(ctx, path, &types.GetHistoricalDataQuery{
 		UserID:    p.userID,
 		DBName:    dbName,
 		Key:       key,
@@ -87,9 +105,13 @@ func (p *provenance) GetNextHistoricalData(dbName, key string, version *types.Ve
 }
 
 func (p *provenance) GetDataReadByUser(userID string) ([]*types.KVWithMetadata, error) {
+	return p.GetDataReadByUserCtx(context.Background(), userID)
+}
+
+func (p *provenance) GetDataReadByUserCtx(ctx context.Context, userID string) ([]*types.KVWithMetadata, error) {
 	path := constants.URLForGetDataReadBy(userID)
 	res := &types.GetDataProvenanceResponse{}
-	err := p.handleRequest(path, &types.GetDataReadByQuery{
+	err := p.handleRequest(ctx, path, &types.GetDataReadByQuery{
 		UserID:       p.userID,
 		TargetUserID: userID,
 	}, res)
@@ -101,9 +123,13 @@ func (p *provenance) GetDataReadByUser(userID string) ([]*types.KVWithMetadata,
 }
 
 func (p *provenance) GetDataWrittenByUser(userID string) ([]*types.KVWithMetadata, error) {
+	return p.GetDataWrittenByUserCtx(context.Background(), userID)
+}
+
+func (p *provenance) GetDataWrittenByUserCtx(ctx context.Context, userID string) ([]*types.KVWithMetadata, error) {
 	path := constants.URLForGetDataWrittenBy(userID)
 	res := &types.GetDataProvenanceResponse{}
-	err := p.handleRequest(path, &types.GetDataWrittenByQuery{
+	err := p.handleRequest(ctx, path, &types.GetDataWrittenByQuery{
 		UserID:       p.userID,
 		TargetUserID: userID,
 	}, res)
@@ -115,9 +141,13 @@ func (p *provenance) GetDataWrittenByUser(userID string) ([]*types.KVWithMetadat
 }
 
 func (p *provenance) GetReaders(dbName, key string) ([]string, error) {
+	return p.GetReadersCtx(context.Background(), dbName, key)
+}
+
+func (p *provenance) GetReadersCtx(ctx context.Context, dbName, key string) ([]string, error) {
 	path := constants.URLForGetDataReaders(dbName, key)
 	res := &types.GetDataReadersResponse{}
-	err := p.handleRequest(path, &types.GetDataReadersQuery{
+	err := p.handleRequest(ctx, path, &types.GetDataReadersQuery{
 		UserID: p.userID,
 		DBName: dbName,
 		Key:    key,
@@ -138,9 +168,13 @@ func (p *provenance) GetReaders(dbName, key string) ([]string, error) {
 }
 
 func (p *provenance) GetWriters(dbName, key string) ([]string, error) {
+	return p.GetWritersCtx(context.Background(), dbName, key)
+}
+
+func (p *provenance) GetWritersCtx(ctx context.Context, dbName, key string) ([]string, error) {
 	path := constants.URLForGetDataWriters(dbName, key)
 	res := &types.GetDataWritersResponse{}
-	err := p.handleRequest(path, &types.GetDataWritersQuery{
+	err := p.handleRequest(ctx, path, &types.GetDataWritersQuery{
 		UserID: p.userID,
 		DBName: dbName,
 		Key:    key,
@@ -161,9 +195,13 @@ func (p *provenance) GetWriters(dbName, key string) ([]string, error) {
 }
 
 func (p *provenance) GetTxIDsSubmittedByUser(userID string) ([]string, error) {
+	return p.GetTxIDsSubmittedByUserCtx(context.Background(), userID)
+}
+
+func (p *provenance) GetTxIDsSubmittedByUserCtx(ctx context.Context, userID string) ([]string, error) {
 	path := constants.URLForGetTxIDsSubmittedBy(userID)
 	res := &types.GetTxIDsSubmittedByResponse{}
-	err := p.handleRequest(path, &types.GetTxIDsSubmittedByQuery{
+	err := p.handleRequest(ctx, path, &types.GetTxIDsSubmittedByQuery{
 		UserID:       p.userID,
 		TargetUserID: userID,
 	}, res)
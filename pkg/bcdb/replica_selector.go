@@ -0,0 +1,336 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	defaultHealthCheckTimeout  = time.Second
+	// latencySmoothingFactor weight of the latest latency sample in latency moving average
+	latencySmoothingFactor = 0.3
+)
+
+// ErrNoReplicaAvailable returned when there is no replica to send request to
+var ErrNoReplicaAvailable = errors.New("no replica available to serve the request")
+
+// replicaSelector orders replicas according to selection strategy and
+// keeps track of replicas health. Replica marked unhealthy after connection error
+// and probed in the background until it accepts connections again.
+type replicaSelector struct {
+	mu                  sync.Mutex
+	strategy            config.ReplicaSelectionStrategy
+	healthCheckInterval time.Duration
+	healthCheckTimeout  time.Duration
+	replicas            []*replicaState
+	// current index of replica used by sticky strategy, next replica for round-robin
+	current int
+	probe   func(replica *url.URL, timeout time.Duration) error
+	// done is closed by close, stops probing of unhealthy replicas
+	done      chan struct{}
+	closeOnce sync.Once
+	logger    *logger.SugarLogger
+}
+
+type replicaState struct {
+	id      string
+	url     *url.URL
+	healthy bool
+	probing bool
+	// removed replica was removed from the replica set, stops probing
+	removed bool
+	// moving average of successful requests latency, 0 if no request completed yet
+	latency time.Duration
+}
+
+func newReplicaSelector(replicaSet map[string]*url.URL, cfg *config.ReplicaSelectionConfig, logger *logger.SugarLogger) (*replicaSelector, error) {
+	s := &replicaSelector{
+		strategy:            config.ReplicaSelectionSticky,
+		healthCheckInterval: defaultHealthCheckInterval,
+		healthCheckTimeout:  defaultHealthCheckTimeout,
+		probe:               dialReplica,
+		done:                make(chan struct{}),
+		logger:              logger,
+	}
+
+	if cfg != nil {
+		switch cfg.Strategy {
+		case "":
+		case config.ReplicaSelectionSticky, config.ReplicaSelectionRoundRobin, config.ReplicaSelectionLeastLatency:
+			s.strategy = cfg.Strategy
+		default:
+			return nil, errors.Errorf("unknown replica selection strategy: %s", cfg.Strategy)
+		}
+		if cfg.HealthCheckInterval > 0 {
+			s.healthCheckInterval = cfg.HealthCheckInterval
+		}
+		if cfg.HealthCheckTimeout > 0 {
+			s.healthCheckTimeout = cfg.HealthCheckTimeout
+		}
+	}
+
+	for id, replicaURL := range replicaSet {
+		s.replicas = append(s.replicas, &replicaState{
+			id:      id,
+			url:     replicaURL,
+			healthy: true,
+		})
+	}
+	// Keep replicas order deterministic, map iteration order is random
+	sort.Slice(s.replicas, func(i, j int) bool {
+		return s.replicas[i].id < s.replicas[j].id
+	})
+
+	return s, nil
+}
+
+// update replaces the set of replicas, health and latency of replicas remaining in the set are kept
+func (s *replicaSelector) update(replicaSet map[string]*url.URL) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := map[string]*replicaState{}
+	for _, r := range s.replicas {
+		existing[r.id] = r
+	}
+	var currentID string
+	if len(s.replicas) > 0 {
+		currentID = s.replicas[s.current%len(s.replicas)].id
+	}
+
+	replicas := make([]*replicaState, 0, len(replicaSet))
+	for id, replicaURL := range replicaSet {
+		if r, ok := existing[id]; ok && r.url.String() == replicaURL.String() {
+			replicas = append(replicas, r)
+			delete(existing, id)
+			continue
+		}
+		replicas = append(replicas, &replicaState{
+			id:      id,
+			url:     replicaURL,
+			healthy: true,
+		})
+	}
+	for _, r := range existing {
+		r.removed = true
+	}
+	sort.Slice(replicas, func(i, j int) bool {
+		return replicas[i].id < replicas[j].id
+	})
+
+	s.replicas = replicas
+	s.current = 0
+	for i, r := range replicas {
+		if r.id == currentID {
+			s.current = i
+		}
+	}
+}
+
+// replicaSet returns current replicas by ID
+func (s *replicaSelector) replicaSet() map[string]*url.URL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replicaSet := make(map[string]*url.URL, len(s.replicas))
+	for _, r := range s.replicas {
+		replicaSet[r.id] = r.url
+	}
+	return replicaSet
+}
+
+// selectedReplica is a replica picked by replicaSelector to serve the request
+type selectedReplica struct {
+	id  string
+	url *url.URL
+}
+
+// candidates returns replicas in the order they should be tried to serve single request.
+// Healthy replicas returned first, in order defined by selection strategy, followed by
+// unhealthy replicas, which are used only if no healthy replica accepted the request
+func (s *replicaSelector) candidates() []*selectedReplica {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.replicas)
+	if n == 0 {
+		return nil
+	}
+
+	ordered := make([]*replicaState, 0, n)
+	switch s.strategy {
+	case config.ReplicaSelectionRoundRobin:
+		start := s.current % n
+		s.current = (start + 1) % n
+		for i := 0; i < n; i++ {
+			ordered = append(ordered, s.replicas[(start+i)%n])
+		}
+	case config.ReplicaSelectionLeastLatency:
+		ordered = append(ordered, s.replicas...)
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].latency < ordered[j].latency
+		})
+	default:
+		start := s.current % n
+		for i := 0; i < n; i++ {
+			ordered = append(ordered, s.replicas[(start+i)%n])
+		}
+	}
+
+	var healthy, unhealthy []*selectedReplica
+	for _, r := range ordered {
+		if r.healthy {
+			healthy = append(healthy, &selectedReplica{id: r.id, url: r.url})
+		} else {
+			unhealthy = append(unhealthy, &selectedReplica{id: r.id, url: r.url})
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// markSuccess records successful request served by replica
+func (s *replicaSelector) markSuccess(replicaID string, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, r := range s.replicas {
+		if r.id != replicaID {
+			continue
+		}
+		r.healthy = true
+		// latency is not tracked for transaction submission, since it depends on block creation time
+		if latency > 0 {
+			if r.latency == 0 {
+				r.latency = latency
+			} else {
+				r.latency = time.Duration(latencySmoothingFactor*float64(latency) + (1-latencySmoothingFactor)*float64(r.latency))
+			}
+		}
+		if s.strategy == config.ReplicaSelectionSticky {
+			s.current = i
+		}
+		return
+	}
+}
+
+// markFailure marks replica as unhealthy and starts probing it in the background
+func (s *replicaSelector) markFailure(replicaID string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, r := range s.replicas {
+		if r.id != replicaID {
+			continue
+		}
+		if r.healthy {
+			s.logger.Warnf("replica %s marked unhealthy, due to %s", replicaID, err)
+		}
+		r.healthy = false
+		if s.strategy == config.ReplicaSelectionSticky && s.current == i {
+			s.current = (i + 1) % len(s.replicas)
+		}
+		if !r.probing {
+			r.probing = true
+			go s.probeUntilHealthy(r)
+		}
+		return
+	}
+}
+
+func (s *replicaSelector) probeUntilHealthy(r *replicaState) {
+	ticker := time.NewTicker(s.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		healthy := r.healthy
+		removed := r.removed
+		s.mu.Unlock()
+
+		if removed {
+			return
+		}
+		if !healthy {
+			if err := s.probe(r.url, s.healthCheckTimeout); err != nil {
+				s.logger.Debugf("replica %s is still unhealthy, due to %s", r.id, err)
+				continue
+			}
+			s.logger.Infof("replica %s is healthy again", r.id)
+		}
+
+		s.mu.Lock()
+		r.healthy = true
+		r.probing = false
+		s.mu.Unlock()
+		return
+	}
+}
+
+// close stops probing of unhealthy replicas
+func (s *replicaSelector) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// dialReplica checks whenever replica accepts connections
+func dialReplica(replica *url.URL, timeout time.Duration) error {
+	host := replica.Host
+	if replica.Port() == "" {
+		switch replica.Scheme {
+		case "https":
+			host = net.JoinHostPort(replica.Hostname(), "443")
+		default:
+			host = net.JoinHostPort(replica.Hostname(), "80")
+		}
+	}
+	conn, err := net.DialTimeout("tcp", host, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// isConnectionError checks whenever request failed since replica wasn't reachable,
+// therefore it is safe to retry request with other replica
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// isDialError checks whenever request failed before connection to replica established,
+// i.e. request never reached replica
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isMaybeDeliveredError checks whenever request failed after it might have reached replica, i.e.
+// connection was dropped or timed out while waiting for the response
+func isMaybeDeliveredError(err error) bool {
+	if isDialError(err) {
+		return false
+	}
+	if isConnectionError(err) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && urlErr.Timeout()
+}
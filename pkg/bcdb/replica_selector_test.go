@@ -0,0 +1,264 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/bcdb/mocks"
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testReplicaSet() map[string]*url.URL {
+	return map[string]*url.URL{
+		"node1": {Scheme: "http", Host: "node1:6001"},
+		"node2": {Scheme: "http", Host: "node2:6001"},
+		"node3": {Scheme: "http", Host: "node3:6001"},
+	}
+}
+
+func candidateIDs(s *replicaSelector) []string {
+	var ids []string
+	for _, r := range s.candidates() {
+		ids = append(ids, r.id)
+	}
+	return ids
+}
+
+func TestReplicaSelector_Strategies(t *testing.T) {
+	logger := createTestLogger(t)
+	connErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	neverHealthy := func(*url.URL, time.Duration) error { return connErr }
+
+	t.Run("sticky", func(t *testing.T) {
+		s, err := newReplicaSelector(testReplicaSet(), nil, logger)
+		require.NoError(t, err)
+		s.probe = neverHealthy
+
+		require.Equal(t, []string{"node1", "node2", "node3"}, candidateIDs(s))
+		require.Equal(t, []string{"node1", "node2", "node3"}, candidateIDs(s))
+
+		s.markFailure("node1", connErr)
+		require.Equal(t, []string{"node2", "node3", "node1"}, candidateIDs(s))
+		s.markSuccess("node2", time.Millisecond)
+		require.Equal(t, []string{"node2", "node3", "node1"}, candidateIDs(s))
+	})
+
+	t.Run("round-robin", func(t *testing.T) {
+		s, err := newReplicaSelector(testReplicaSet(), &config.ReplicaSelectionConfig{
+			Strategy: config.ReplicaSelectionRoundRobin,
+		}, logger)
+		require.NoError(t, err)
+		s.probe = neverHealthy
+
+		require.Equal(t, []string{"node1", "node2", "node3"}, candidateIDs(s))
+		require.Equal(t, []string{"node2", "node3", "node1"}, candidateIDs(s))
+		require.Equal(t, []string{"node3", "node1", "node2"}, candidateIDs(s))
+
+		s.markFailure("node2", connErr)
+		require.Equal(t, []string{"node1", "node3", "node2"}, candidateIDs(s))
+		require.Equal(t, []string{"node3", "node1", "node2"}, candidateIDs(s))
+	})
+
+	t.Run("least-latency", func(t *testing.T) {
+		s, err := newReplicaSelector(testReplicaSet(), &config.ReplicaSelectionConfig{
+			Strategy: config.ReplicaSelectionLeastLatency,
+		}, logger)
+		require.NoError(t, err)
+		s.probe = neverHealthy
+
+		s.markSuccess("node1", 30*time.Millisecond)
+		s.markSuccess("node2", 10*time.Millisecond)
+		s.markSuccess("node3", 20*time.Millisecond)
+		require.Equal(t, []string{"node2", "node3", "node1"}, candidateIDs(s))
+
+		s.markFailure("node2", connErr)
+		require.Equal(t, []string{"node3", "node1", "node2"}, candidateIDs(s))
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		s, err := newReplicaSelector(testReplicaSet(), &config.ReplicaSelectionConfig{
+			Strategy: "random",
+		}, logger)
+		require.EqualError(t, err, "unknown replica selection strategy: random")
+		require.Nil(t, s)
+	})
+}
+
+func TestReplicaSelector_ProbeUnhealthy(t *testing.T) {
+	logger := createTestLogger(t)
+	s, err := newReplicaSelector(testReplicaSet(), &config.ReplicaSelectionConfig{
+		HealthCheckInterval: 10 * time.Millisecond,
+	}, logger)
+	require.NoError(t, err)
+
+	var probes int32
+	s.probe = func(replica *url.URL, _ time.Duration) error {
+		if atomic.AddInt32(&probes, 1) < 3 {
+			return &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+		}
+		return nil
+	}
+
+	s.markFailure("node1", errors.New("connection refused"))
+	require.Equal(t, []string{"node2", "node3", "node1"}, candidateIDs(s))
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.replicas[0].healthy && !s.replicas[0].probing
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(3), atomic.LoadInt32(&probes))
+	// sticky strategy keeps using the replica selected after the failure
+	require.Equal(t, []string{"node2", "node3", "node1"}, candidateIDs(s))
+}
+
+func TestReplicaSelector_Close(t *testing.T) {
+	s, err := newReplicaSelector(testReplicaSet(), &config.ReplicaSelectionConfig{
+		HealthCheckInterval: 5 * time.Millisecond,
+	}, createTestLogger(t))
+	require.NoError(t, err)
+	var probes int32
+	s.probe = func(*url.URL, time.Duration) error {
+		atomic.AddInt32(&probes, 1)
+		return &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	}
+
+	s.markFailure("node1", errors.New("connection refused"))
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&probes) > 0 }, time.Second, 5*time.Millisecond)
+
+	s.close()
+	s.close()
+	time.Sleep(20 * time.Millisecond)
+	stoppedAt := atomic.LoadInt32(&probes)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, stoppedAt, atomic.LoadInt32(&probes))
+}
+
+func TestReplicaSelector_Update(t *testing.T) {
+	logger := createTestLogger(t)
+	s, err := newReplicaSelector(testReplicaSet(), &config.ReplicaSelectionConfig{
+		HealthCheckInterval: 10 * time.Millisecond,
+	}, logger)
+	require.NoError(t, err)
+	var probes int32
+	s.probe = func(*url.URL, time.Duration) error {
+		atomic.AddInt32(&probes, 1)
+		return &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	}
+
+	s.markFailure("node1", errors.New("connection refused"))
+	s.markFailure("node2", errors.New("connection refused"))
+	require.Equal(t, []string{"node3", "node1", "node2"}, candidateIDs(s))
+
+	s.update(map[string]*url.URL{
+		"node2": {Scheme: "http", Host: "node2:6001"},
+		"node3": {Scheme: "http", Host: "node3:6001"},
+		"node4": {Scheme: "http", Host: "node4:6001"},
+	})
+	// node2 is still unhealthy, sticky strategy keeps using node3
+	require.Equal(t, []string{"node3", "node4", "node2"}, candidateIDs(s))
+	require.Equal(t, map[string]*url.URL{
+		"node2": {Scheme: "http", Host: "node2:6001"},
+		"node3": {Scheme: "http", Host: "node3:6001"},
+		"node4": {Scheme: "http", Host: "node4:6001"},
+	}, s.replicaSet())
+
+	// node2 endpoint changed, new endpoint is considered healthy
+	s.update(map[string]*url.URL{
+		"node2": {Scheme: "http", Host: "10.0.0.2:6001"},
+		"node3": {Scheme: "http", Host: "node3:6001"},
+	})
+	require.Equal(t, []string{"node3", "node2"}, candidateIDs(s))
+
+	// probing of removed replicas stops
+	time.Sleep(50 * time.Millisecond)
+	probesAfterRemoval := atomic.LoadInt32(&probes)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, probesAfterRemoval, atomic.LoadInt32(&probes))
+}
+
+func TestTxQuery_FailoverToNextReplica(t *testing.T) {
+	emptySigner := &mocks.Signer{}
+	emptySigner.On("Sign", mock.Anything).Return([]byte{1}, nil)
+	logger := createTestLogger(t)
+
+	var node1Requests, node2Requests int32
+	httpClient := &mockHttpClient{
+		process: func(req *http.Request, resp *http.Response) (*http.Response, error) {
+			if req.URL.Host == "node1:6001" {
+				atomic.AddInt32(&node1Requests, 1)
+				return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+			}
+			atomic.AddInt32(&node2Requests, 1)
+			return okDataQueryResponse(), nil
+		},
+	}
+
+	replicaSet := testReplicaSet()
+	delete(replicaSet, "node3")
+	selector, err := newReplicaSelector(replicaSet, nil, logger)
+	require.NoError(t, err)
+	selector.probe = func(*url.URL, time.Duration) error {
+		return errors.New("connection refused")
+	}
+
+	txCtx := &commonTxContext{
+		userID:          "testUser",
+		signer:          emptySigner,
+		userCert:        []byte{1, 2, 3},
+		replicaSet:      replicaSet,
+		replicaSelector: selector,
+		nodesCerts:      testNodesCerts(),
+		restClient:      NewRestClient("testUser", httpClient, emptySigner),
+		logger:          logger,
+	}
+
+	for i := 0; i < 3; i++ {
+		res := &types.GetDataResponse{}
+		err = txCtx.handleRequest(context.Background(), constants.URLForGetData("bdb", "key1"), &types.GetDataQuery{
+			UserID: "testUser",
+			DBName: "bdb",
+			Key:    "key1",
+		}, res)
+		require.NoError(t, err)
+		require.Equal(t, []byte{1}, res.GetValue())
+	}
+
+	// node1 marked unhealthy after the first failure, therefore tried only once
+	require.Equal(t, int32(1), atomic.LoadInt32(&node1Requests))
+	require.Equal(t, int32(3), atomic.LoadInt32(&node2Requests))
+}
+
+func TestIsMaybeDeliveredError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		delivered bool
+	}{
+		{name: "dial", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}},
+		{name: "connection reset", err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, delivered: true},
+		{name: "connection closed", err: &url.Error{Op: "Post", URL: "http://node1:6001", Err: io.EOF}, delivered: true},
+		{name: "response truncated", err: &url.Error{Op: "Post", URL: "http://node1:6001", Err: io.ErrUnexpectedEOF}, delivered: true},
+		{name: "timeout", err: &url.Error{Op: "Post", URL: "http://node1:6001", Err: &timeoutError{}}, delivered: true},
+		{name: "malformed request", err: &url.Error{Op: "Post", URL: "node1", Err: errors.New("unsupported protocol scheme")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.delivered, isMaybeDeliveredError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrUnknownNode returned when response signed by node which is not part of the cluster config
+	ErrUnknownNode = errors.New("response signed by unknown node")
+	// ErrBadSignature returned when response signature doesn't match node's certificate
+	ErrBadSignature = errors.New("response signature verification failed")
+)
+
+// ResponseVerificationError returned when the server's response can't be
+// authenticated, either because it was signed by the node unknown to the
+// SDK or because signature over response payload is not valid
+type ResponseVerificationError struct {
+	// NodeID the ID of the node claimed to sign the response
+	NodeID string
+	// Err one of ErrUnknownNode or ErrBadSignature, with optional details
+	Err error
+}
+
+func (e *ResponseVerificationError) Error() string {
+	return fmt.Sprintf("failed to verify response from node [%s]: %s", e.NodeID, e.Err)
+}
+
+func (e *ResponseVerificationError) Unwrap() error {
+	return e.Err
+}
+
+// verifyResponseSignature validates signature over response envelope payload,
+// using certificate of the node which signed the payload
+func verifyResponseSignature(nodesCerts map[string]*x509.Certificate, resEnv *types.ResponseEnvelope, payload *types.Payload) error {
+	nodeID := payload.GetHeader().GetNodeID()
+	cert, ok := nodesCerts[nodeID]
+	if !ok || cert == nil {
+		return &ResponseVerificationError{NodeID: nodeID, Err: ErrUnknownNode}
+	}
+
+	sigAlgorithm, err := signatureAlgorithm(cert)
+	if err != nil {
+		return &ResponseVerificationError{NodeID: nodeID, Err: errors.WithMessage(ErrBadSignature, err.Error())}
+	}
+
+	if err = cert.CheckSignature(sigAlgorithm, resEnv.GetPayload(), resEnv.GetSignature()); err != nil {
+		return &ResponseVerificationError{NodeID: nodeID, Err: errors.WithMessage(ErrBadSignature, err.Error())}
+	}
+	return nil
+}
+
+func signatureAlgorithm(cert *x509.Certificate) (x509.SignatureAlgorithm, error) {
+	switch cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		return x509.ECDSAWithSHA256, nil
+	case *rsa.PublicKey:
+		return x509.SHA256WithRSA, nil
+	case ed25519.PublicKey:
+		return x509.PureEd25519, nil
+	default:
+		return x509.UnknownSignatureAlgorithm, errors.Errorf("unsupported public key type %T", cert.PublicKey)
+	}
+}
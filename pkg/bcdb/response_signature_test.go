@@ -0,0 +1,190 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/bcdb/mocks"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testNodeIdentity used to sign mocked server responses, signed on behalf of "node1"
+var testNodeIdentity = newTestNode("node1")
+
+type testNode struct {
+	id   string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestNode(id string) *testNode {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: id},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(certBytes)
+	if err != nil {
+		panic(err)
+	}
+	return &testNode{id: id, cert: cert, key: key}
+}
+
+func (n *testNode) sign(payload []byte) []byte {
+	digest := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, n.key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+func testNodesCerts() map[string]*x509.Certificate {
+	return map[string]*x509.Certificate{
+		testNodeIdentity.id: testNodeIdentity.cert,
+	}
+}
+
+func TestVerifyResponseSignature(t *testing.T) {
+	otherNode := newTestNode("node2")
+	payload := &types.Payload{
+		Header: &types.ResponseHeader{
+			NodeID: "node1",
+		},
+		Response: MarshalOrPanic(&types.GetDataResponse{
+			Value: []byte{1},
+		}),
+	}
+	payloadBytes := MarshalOrPanic(payload)
+
+	tests := []struct {
+		name        string
+		nodesCerts  map[string]*x509.Certificate
+		envelope    *types.ResponseEnvelope
+		payload     *types.Payload
+		expectedErr error
+	}{
+		{
+			name:       "valid signature",
+			nodesCerts: testNodesCerts(),
+			envelope: &types.ResponseEnvelope{
+				Payload:   payloadBytes,
+				Signature: testNodeIdentity.sign(payloadBytes),
+			},
+			payload: payload,
+		},
+		{
+			name:       "missing signature",
+			nodesCerts: testNodesCerts(),
+			envelope: &types.ResponseEnvelope{
+				Payload: payloadBytes,
+			},
+			payload:     payload,
+			expectedErr: ErrBadSignature,
+		},
+		{
+			name:       "signed by other node",
+			nodesCerts: testNodesCerts(),
+			envelope: &types.ResponseEnvelope{
+				Payload:   payloadBytes,
+				Signature: otherNode.sign(payloadBytes),
+			},
+			payload:     payload,
+			expectedErr: ErrBadSignature,
+		},
+		{
+			name:       "unknown node",
+			nodesCerts: map[string]*x509.Certificate{otherNode.id: otherNode.cert},
+			envelope: &types.ResponseEnvelope{
+				Payload:   payloadBytes,
+				Signature: testNodeIdentity.sign(payloadBytes),
+			},
+			payload:     payload,
+			expectedErr: ErrUnknownNode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyResponseSignature(tt.nodesCerts, tt.envelope, tt.payload)
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.True(t, errors.Is(err, tt.expectedErr))
+			verErr := &ResponseVerificationError{}
+			require.True(t, errors.As(err, &verErr))
+			require.Equal(t, "node1", verErr.NodeID)
+		})
+	}
+}
+
+func TestTxQueryBadSignature(t *testing.T) {
+	emptySigner := &mocks.Signer{}
+	emptySigner.On("Sign", mock.Anything).Return([]byte{1}, nil)
+	logger := createTestLogger(t)
+	otherNode := newTestNode("node1")
+
+	resp := okDataQueryResponse()
+	resEnv := &types.ResponseEnvelope{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(resEnv))
+	resEnv.Signature = otherNode.sign(resEnv.Payload)
+
+	txCtx := &commonTxContext{
+		userID:   "testUser",
+		signer:   emptySigner,
+		userCert: []byte{1, 2, 3},
+		replicaSet: map[string]*url.URL{
+			"node1": {
+				Path: "http://localhost:8888",
+			},
+		},
+		nodesCerts: testNodesCerts(),
+		restClient: NewRestClient("testUser", &mockHttpClient{
+			process: querySleep10,
+			resp: &http.Response{
+				StatusCode: http.StatusOK,
+				Status:     http.StatusText(http.StatusOK),
+				Body:       ioutil.NopCloser(bytes.NewReader(MarshalOrPanic(resEnv))),
+			},
+		}, emptySigner),
+		logger: logger,
+	}
+
+	err := txCtx.handleRequest(context.Background(), constants.URLForGetData("bdb", "key1"), &types.GetDataQuery{
+		UserID: "testUser",
+		DBName: "bdb",
+		Key:    "key1",
+	}, &types.GetDataResponse{})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrBadSignature))
+}
@@ -7,6 +7,8 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"time"
@@ -109,3 +111,12 @@ func (r *restClient) Submit(ctx context.Context, endpoint string, msg proto.Mess
 	}
 	return resp, err
 }
+
+// closeResponseBody reads the rest of the response body and closes it, so the connection can be reused
+func closeResponseBody(response *http.Response) {
+	if response.Body == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, response.Body)
+	response.Body.Close()
+}
@@ -0,0 +1,76 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultRunDataTxMaxAttempts    = 3
+	defaultRunDataTxInitialBackoff = 50 * time.Millisecond
+	defaultRunDataTxMaxBackoff     = time.Second
+)
+
+// RunDataTxOptions controls retries of DBSession.RunDataTx
+type RunDataTxOptions struct {
+	// MaxAttempts maximal number of transaction executions, 3 if 0
+	MaxAttempts int
+	// InitialBackoff delay before the first retry, 50 milliseconds if 0
+	InitialBackoff time.Duration
+	// MaxBackoff delay doubles after each retry up to MaxBackoff, 1 second if 0
+	MaxBackoff time.Duration
+}
+
+// runDataTx executes txFunc within data transaction created by newTx and commits the transaction synchronously.
+// Transaction re-executed on fresh context if it was invalidated due to MVCC conflict
+func runDataTx(ctx context.Context, newTx func(ctx context.Context) (DataTxContext, error), txFunc func(tx DataTxContext) error, opts *RunDataTxOptions) (string, *types.TxReceipt, error) {
+	maxAttempts := defaultRunDataTxMaxAttempts
+	backoff := defaultRunDataTxInitialBackoff
+	maxBackoff := defaultRunDataTxMaxBackoff
+	if opts != nil {
+		if opts.MaxAttempts > 0 {
+			maxAttempts = opts.MaxAttempts
+		}
+		if opts.InitialBackoff > 0 {
+			backoff = opts.InitialBackoff
+		}
+		if opts.MaxBackoff > 0 {
+			maxBackoff = opts.MaxBackoff
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		tx, err := newTx(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if err = txFunc(tx); err != nil {
+			tx.Abort()
+			return "", nil, err
+		}
+
+		txID, receipt, err := tx.CommitCtx(ctx, true)
+		if err == nil || !errors.Is(err, ErrMVCCConflict) {
+			return txID, receipt, err
+		}
+		if attempt >= maxAttempts {
+			return txID, receipt, errors.WithMessagef(err, "transaction failed after %d attempts", attempt)
+		}
+
+		select {
+		case <-ctx.Done():
+			return txID, receipt, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
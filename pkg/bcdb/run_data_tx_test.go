@@ -0,0 +1,117 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDataTx struct {
+	DataTxContext
+	txID    string
+	receipt *types.TxReceipt
+	aborted bool
+}
+
+func (tx *fakeDataTx) CommitCtx(ctx context.Context, sync bool) (string, *types.TxReceipt, error) {
+	return tx.txID, tx.receipt, validateReceipt(tx.txID, tx.receipt)
+}
+
+func (tx *fakeDataTx) Abort() error {
+	tx.aborted = true
+	return nil
+}
+
+func TestRunDataTx(t *testing.T) {
+	opts := &RunDataTxOptions{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}
+
+	newTxs := func(flags ...types.Flag) (func(ctx context.Context) (DataTxContext, error), *[]*fakeDataTx) {
+		var txs []*fakeDataTx
+		return func(ctx context.Context) (DataTxContext, error) {
+			if len(txs) == len(flags) {
+				return nil, errors.New("unexpected transaction")
+			}
+			tx := &fakeDataTx{
+				txID:    fmt.Sprintf("tx%d", len(txs)+1),
+				receipt: receiptWithFlag(flags[len(txs)], ""),
+			}
+			txs = append(txs, tx)
+			return tx, nil
+		}, &txs
+	}
+
+	t.Run("valid after MVCC conflict", func(t *testing.T) {
+		newTx, txs := newTxs(types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE, types.Flag_INVALID_MVCC_CONFLICT_WITHIN_BLOCK, types.Flag_VALID)
+		executions := 0
+		txID, receipt, err := runDataTx(context.Background(), newTx, func(tx DataTxContext) error {
+			executions++
+			return nil
+		}, opts)
+		require.NoError(t, err)
+		require.Equal(t, "tx3", txID)
+		require.NotNil(t, receipt)
+		require.Equal(t, 3, executions)
+		require.Len(t, *txs, 3)
+	})
+
+	t.Run("max attempts exceeded", func(t *testing.T) {
+		newTx, _ := newTxs(types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE, types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE)
+		txID, receipt, err := runDataTx(context.Background(), newTx, func(tx DataTxContext) error {
+			return nil
+		}, &RunDataTxOptions{
+			MaxAttempts:    2,
+			InitialBackoff: time.Millisecond,
+		})
+		require.Equal(t, "tx2", txID)
+		require.NotNil(t, receipt)
+		invalidErr := &TxInvalidError{}
+		require.True(t, errors.As(err, &invalidErr))
+		require.Equal(t, types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE, invalidErr.Flag)
+		require.Contains(t, err.Error(), "transaction failed after 2 attempts")
+	})
+
+	t.Run("invalid without MVCC conflict", func(t *testing.T) {
+		newTx, txs := newTxs(types.Flag_INVALID_NO_PERMISSION)
+		txID, _, err := runDataTx(context.Background(), newTx, func(tx DataTxContext) error {
+			return nil
+		}, opts)
+		require.Equal(t, "tx1", txID)
+		invalidErr := &TxInvalidError{}
+		require.True(t, errors.As(err, &invalidErr))
+		require.Equal(t, types.Flag_INVALID_NO_PERMISSION, invalidErr.Flag)
+		require.Len(t, *txs, 1)
+	})
+
+	t.Run("txFunc error", func(t *testing.T) {
+		newTx, txs := newTxs(types.Flag_VALID)
+		_, receipt, err := runDataTx(context.Background(), newTx, func(tx DataTxContext) error {
+			return errors.New("business logic error")
+		}, opts)
+		require.EqualError(t, err, "business logic error")
+		require.Nil(t, receipt)
+		require.True(t, (*txs)[0].aborted)
+	})
+
+	t.Run("context done during backoff", func(t *testing.T) {
+		newTx, txs := newTxs(types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE, types.Flag_VALID)
+		ctx, cancel := context.WithCancel(context.Background())
+		_, _, err := runDataTx(ctx, newTx, func(tx DataTxContext) error {
+			cancel()
+			return nil
+		}, &RunDataTxOptions{
+			InitialBackoff: time.Minute,
+		})
+		require.Equal(t, context.Canceled, err)
+		require.Len(t, *txs, 1)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultCloseTimeout time DBSession.Close and BCDB.Close wait for in-flight commits to complete
+const defaultCloseTimeout = 30 * time.Second
+
+// sessionLifecycle tracks in-flight commits of the session. Once the session is closed, new contexts,
+// queries and commits are rejected with ErrSessionClosed and the background work, e.g. receipt polling,
+// is stopped. Methods of nil sessionLifecycle are no-op, i.e. context isn't bound to the session lifecycle
+type sessionLifecycle struct {
+	mu       sync.Mutex
+	isClosed bool
+	// closed is closed once the session is closed
+	closed  chan struct{}
+	commits sync.WaitGroup
+}
+
+func newSessionLifecycle() *sessionLifecycle {
+	return &sessionLifecycle{
+		closed: make(chan struct{}),
+	}
+}
+
+// check returns ErrSessionClosed if the session is closed
+func (l *sessionLifecycle) check() error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case <-l.closed:
+		return ErrSessionClosed
+	default:
+		return nil
+	}
+}
+
+// done returns channel closed once the session is closed, nil if context isn't bound to the session
+func (l *sessionLifecycle) done() <-chan struct{} {
+	if l == nil {
+		return nil
+	}
+	return l.closed
+}
+
+// beginCommit registers in-flight commit, endCommit should be called once the commit completes
+func (l *sessionLifecycle) beginCommit() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.isClosed {
+		return ErrSessionClosed
+	}
+	l.commits.Add(1)
+	return nil
+}
+
+func (l *sessionLifecycle) endCommit() {
+	if l == nil {
+		return
+	}
+	l.commits.Done()
+}
+
+// close marks the session closed and waits for in-flight commits until ctx is done.
+// Returns false if the session was already closed
+func (l *sessionLifecycle) close(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	if l.isClosed {
+		l.mu.Unlock()
+		return false, nil
+	}
+	l.isClosed = true
+	close(l.closed)
+	l.mu.Unlock()
+
+	commitsDone := make(chan struct{})
+	go func() {
+		l.commits.Wait()
+		close(commitsDone)
+	}()
+
+	select {
+	case <-commitsDone:
+		return true, nil
+	case <-ctx.Done():
+		return true, errors.Wrap(ctx.Err(), "in-flight commits didn't complete before session was closed")
+	}
+}
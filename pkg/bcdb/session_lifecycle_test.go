@@ -0,0 +1,233 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionLifecycle(t *testing.T) {
+	t.Run("close waits for in-flight commits", func(t *testing.T) {
+		l := newSessionLifecycle()
+		require.NoError(t, l.beginCommit())
+
+		closeErr := make(chan error, 1)
+		go func() {
+			_, err := l.close(context.Background())
+			closeErr <- err
+		}()
+		<-l.done()
+		require.Equal(t, ErrSessionClosed, l.check())
+		require.Equal(t, ErrSessionClosed, l.beginCommit())
+
+		select {
+		case <-closeErr:
+			require.Fail(t, "close returned before in-flight commit completed")
+		case <-time.After(20 * time.Millisecond):
+		}
+		l.endCommit()
+		require.NoError(t, <-closeErr)
+
+		closed, err := l.close(context.Background())
+		require.False(t, closed)
+		require.NoError(t, err)
+	})
+
+	t.Run("close timeout", func(t *testing.T) {
+		l := newSessionLifecycle()
+		require.NoError(t, l.beginCommit())
+		defer l.endCommit()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		closed, err := l.close(ctx)
+		require.True(t, closed)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("unbound context", func(t *testing.T) {
+		var l *sessionLifecycle
+		require.NoError(t, l.check())
+		require.NoError(t, l.beginCommit())
+		l.endCommit()
+		require.Nil(t, l.done())
+	})
+}
+
+func TestSession_Close(t *testing.T) {
+	dir, err := ioutil.TempDir("", "session-close")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "CA")
+	caPath := writeTestPEM(t, dir, "CA.pem", certPEMBlock(ca.cert))
+	nodeCert, nodeKey := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "node1"}})
+	node := &testNode{id: "node1", cert: nodeCert, key: nodeKey}
+	server := newSignedResponseServer(t, func(r *http.Request) (proto.Message, *testNode) {
+		if r.URL.Path == constants.URLForGetConfig() {
+			return testClusterConfigResponse(node), node
+		}
+		return nil, nil
+	})
+	defer server.Close()
+
+	db, err := Create(&config.ConnectionConfig{
+		ReplicaSet: []*config.Replica{{ID: "node1", Endpoint: server.URL}},
+		RootCAs:    []string{caPath},
+		Logger:     createTestLogger(t),
+	})
+	require.NoError(t, err)
+	session, err := db.Session(testSessionConfig(t, ca))
+	require.NoError(t, err)
+	otherSession, err := db.Session(testSessionConfig(t, ca))
+	require.NoError(t, err)
+
+	tx, err := session.DataTx()
+	require.NoError(t, err)
+	require.NoError(t, tx.Put("bdb", "key", []byte("value"), nil))
+
+	require.NoError(t, session.Close())
+	require.NoError(t, session.Close())
+
+	_, err = session.DataTx()
+	require.Equal(t, ErrSessionClosed, err)
+	_, err = session.Ledger()
+	require.Equal(t, ErrSessionClosed, err)
+	_, _, err = tx.Get("bdb", "other")
+	require.Equal(t, ErrSessionClosed, err)
+	_, _, err = tx.Commit(false)
+	require.Equal(t, ErrSessionClosed, err)
+
+	// other sessions are not affected
+	_, err = otherSession.DataTx()
+	require.NoError(t, err)
+	require.Len(t, db.(*bDB).sessions, 1)
+
+	require.NoError(t, db.Close())
+	_, err = otherSession.DataTx()
+	require.Equal(t, ErrSessionClosed, err)
+	_, err = db.Session(testSessionConfig(t, ca))
+	require.True(t, errors.Is(err, ErrSessionClosed))
+	require.EqualError(t, err, "BCDB instance is closed: session is closed")
+}
+
+func TestSession_CloseReleasesConnections(t *testing.T) {
+	dir, err := ioutil.TempDir("", "session-close")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "CA")
+	caPath := writeTestPEM(t, dir, "CA.pem", certPEMBlock(ca.cert))
+	nodeCert, nodeKey := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "node1"}})
+	node := &testNode{id: "node1", cert: nodeCert, key: nodeKey}
+	// large value is sent with chunked encoding, the end of the body isn't read by JSON decoder
+	largeValue := bytes.Repeat([]byte("v"), 64*1024)
+	signed := signedResponseHandler(t, func(r *http.Request) (proto.Message, *testNode) {
+		switch r.URL.Path {
+		case constants.URLForGetConfig():
+			return testClusterConfigResponse(node), node
+		case constants.URLForGetData("bdb", "key"):
+			return &types.GetDataResponse{Value: largeValue}, node
+		default:
+			return nil, nil
+		}
+	})
+	var failConfig int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == constants.PostDataTx:
+			// server timeout, response body isn't read by the client
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"error": "timeout"}`))
+		case r.URL.Path == constants.URLForGetConfig() && atomic.LoadInt32(&failConfig) == 1:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "internal error"}`))
+		default:
+			signed.ServeHTTP(w, r)
+		}
+	}))
+	var openConns int32
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			atomic.AddInt32(&openConns, 1)
+		case http.StateClosed, http.StateHijacked:
+			atomic.AddInt32(&openConns, -1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	db, err := Create(&config.ConnectionConfig{
+		ReplicaSet: []*config.Replica{{ID: "node1", Endpoint: server.URL}},
+		RootCAs:    []string{caPath},
+		Logger:     createTestLogger(t),
+	})
+	require.NoError(t, err)
+	session, err := db.Session(testSessionConfig(t, ca))
+	require.NoError(t, err)
+
+	// successful and failed queries and commits
+	for i := 0; i < 3; i++ {
+		tx, err := session.DataTx()
+		require.NoError(t, err)
+		value, _, err := tx.Get("bdb", "key")
+		require.NoError(t, err)
+		require.Equal(t, largeValue, value)
+		_, _, err = tx.Get("bdb", "other")
+		serverErr := &ServerError{}
+		require.True(t, errors.As(err, &serverErr))
+		require.NoError(t, tx.Put("bdb", "key", []byte("value"), nil))
+		_, _, err = tx.Commit(true)
+		serverTimeout := &ServerTimeout{}
+		require.True(t, errors.As(err, &serverTimeout))
+	}
+	atomic.StoreInt32(&failConfig, 1)
+	otherSession, err := db.Session(testSessionConfig(t, ca))
+	require.NoError(t, err)
+	_, err = otherSession.DataTx()
+	require.EqualError(t, err, "failed to obtain server's certificate")
+	require.NotZero(t, atomic.LoadInt32(&openConns))
+
+	require.NoError(t, session.Close())
+	require.NoError(t, db.Close())
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&openConns) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTxFuture_SessionClosed(t *testing.T) {
+	lifecycle := newSessionLifecycle()
+	fetch := func(ctx context.Context, txID string) (*types.TxReceipt, error) {
+		return nil, errors.New("not found")
+	}
+	f := newPollingTxFuture("tx1", fetch, &config.ReceiptPollingConfig{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		Timeout:         time.Minute,
+	}, lifecycle.done())
+
+	_, err := lifecycle.close(context.Background())
+	require.NoError(t, err)
+	receipt, err := f.Wait(context.Background())
+	require.Nil(t, receipt)
+	require.True(t, errors.Is(err, ErrSessionClosed))
+}
@@ -0,0 +1,54 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/pkg/errors"
+)
+
+// newTLSConfig builds client TLS configuration, server certificate is verified with CA certificates
+// from the configuration or, if none are given, with rootCAs. Default settings are used if c is nil
+func newTLSConfig(c *config.TLSConfig, rootCAs *x509.CertPool) (*tls.Config, error) {
+	if c == nil {
+		c = &config.TLSConfig{}
+	}
+	tlsConfig := &tls.Config{
+		RootCAs:    rootCAs,
+		ServerName: c.ServerNameOverride,
+		MinVersion: c.MinVersion,
+	}
+	if tlsConfig.MinVersion == 0 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+
+	if len(c.CACertsPaths) > 0 {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		for _, caPath := range c.CACertsPaths {
+			caBytes, err := ioutil.ReadFile(caPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read TLS CA certificate from %s", caPath)
+			}
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(caBytes) {
+				return nil, errors.Errorf("no PEM encoded TLS CA certificates found in %s", caPath)
+			}
+		}
+	}
+
+	if c.ClientCertPath != "" || c.ClientKeyPath != "" {
+		if c.ClientCertPath == "" || c.ClientKeyPath == "" {
+			return nil, errors.New("both TLS client certificate and key should be provided for mutual TLS")
+		}
+		clientCert, err := tls.LoadX509KeyPair(c.ClientCertPath, c.ClientKeyPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load TLS client certificate %s and key %s", c.ClientCertPath, c.ClientKeyPath)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,310 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certBytes)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue issues the certificate signed by the CA, returns certificate and its private key
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if template.KeyUsage == 0 {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certBytes)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writeTestPEM(t *testing.T, dir, name string, blocks ...*pem.Block) string {
+	var pemBytes []byte
+	for _, block := range blocks {
+		pemBytes = append(pemBytes, pem.EncodeToMemory(block)...)
+	}
+	filePath := path.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(filePath, pemBytes, 0600))
+	return filePath
+}
+
+func certPEMBlock(cert *x509.Certificate) *pem.Block {
+	return &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}
+}
+
+func keyPEMBlock(t *testing.T, key *ecdsa.PrivateKey) *pem.Block {
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}
+}
+
+func TestTLSConnection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "CA")
+	otherCA := newTestCA(t, "other CA")
+	serverCert, serverKey := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "node1"},
+		DNSNames:    []string{"node1.bcdb.example"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	clientCert, clientKey := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "alice"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	caPath := writeTestPEM(t, dir, "CA.pem", certPEMBlock(ca.cert))
+	otherCAPath := writeTestPEM(t, dir, "otherCA.pem", certPEMBlock(otherCA.cert))
+	clientCertPath := writeTestPEM(t, dir, "client.pem", certPEMBlock(clientCert))
+	clientKeyPath := writeTestPEM(t, dir, "client.key", keyPEMBlock(t, clientKey))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{
+			{
+				Certificate: [][]byte{serverCert.Raw},
+				PrivateKey:  serverKey,
+			},
+		},
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MaxVersion: tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	newClient := func(t *testing.T, tlsConfig *config.TLSConfig) *http.Client {
+		db, err := Create(&config.ConnectionConfig{
+			ReplicaSet: []*config.Replica{
+				{
+					ID:       "node1",
+					Endpoint: server.URL,
+				},
+			},
+			RootCAs: []string{caPath},
+			TLS:     tlsConfig,
+			Logger:  createTestLogger(t),
+		})
+		require.NoError(t, err)
+		session := &dbSession{tlsConfig: db.(*bDB).tlsConfig}
+		return session.newHTTPClient()
+	}
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		client := newClient(t, &config.TLSConfig{
+			ClientCertPath: clientCertPath,
+			ClientKeyPath:  clientKeyPath,
+		})
+		res, err := client.Get(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("server name override", func(t *testing.T) {
+		client := newClient(t, &config.TLSConfig{
+			CACertsPaths:       []string{caPath},
+			ClientCertPath:     clientCertPath,
+			ClientKeyPath:      clientKeyPath,
+			ServerNameOverride: "node1.bcdb.example",
+		})
+		res, err := client.Get(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		client = newClient(t, &config.TLSConfig{
+			ClientCertPath:     clientCertPath,
+			ClientKeyPath:      clientKeyPath,
+			ServerNameOverride: "node2.bcdb.example",
+		})
+		_, err = client.Get(server.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "certificate is valid for node1.bcdb.example")
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		client := newClient(t, &config.TLSConfig{})
+		_, err := client.Get(server.URL)
+		require.Error(t, err)
+	})
+
+	t.Run("server certificate not issued by CA", func(t *testing.T) {
+		client := newClient(t, &config.TLSConfig{
+			CACertsPaths:   []string{otherCAPath},
+			ClientCertPath: clientCertPath,
+			ClientKeyPath:  clientKeyPath,
+		})
+		_, err := client.Get(server.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "certificate signed by unknown authority")
+	})
+
+	t.Run("minimal TLS version", func(t *testing.T) {
+		client := newClient(t, &config.TLSConfig{
+			ClientCertPath: clientCertPath,
+			ClientKeyPath:  clientKeyPath,
+			MinVersion:     tls.VersionTLS13,
+		})
+		_, err := client.Get(server.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "protocol version")
+	})
+}
+
+func TestNewTLSConfig_Errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "CA")
+	clientCert, _ := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}})
+	clientCertPath := writeTestPEM(t, dir, "client.pem", certPEMBlock(clientCert))
+	notPEMPath := path.Join(dir, "CA.pem")
+	require.NoError(t, ioutil.WriteFile(notPEMPath, []byte("not a certificate"), 0600))
+
+	tlsConfig, err := newTLSConfig(&config.TLSConfig{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	tlsConfig, err = newTLSConfig(nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+
+	_, err = newTLSConfig(&config.TLSConfig{CACertsPaths: []string{notPEMPath}}, nil)
+	require.EqualError(t, err, "no PEM encoded TLS CA certificates found in "+notPEMPath)
+
+	_, err = newTLSConfig(&config.TLSConfig{ClientCertPath: clientCertPath}, nil)
+	require.EqualError(t, err, "both TLS client certificate and key should be provided for mutual TLS")
+
+	_, err = newTLSConfig(&config.TLSConfig{ClientCertPath: clientCertPath, ClientKeyPath: path.Join(dir, "client.key")}, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to load TLS client certificate")
+}
+
+func TestTLSConnection_RootCAs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rootCA := newTestCA(t, "root CA")
+	intermediateCert, intermediateKey := rootCA.issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "intermediate CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	})
+	intermediateCA := &testCA{cert: intermediateCert, key: intermediateKey}
+	serverCert, serverKey := intermediateCA.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "node1"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	rootPath := writeTestPEM(t, dir, "root.pem", certPEMBlock(rootCA.cert))
+	bundlePath := writeTestPEM(t, dir, "bundle.pem", certPEMBlock(intermediateCert), certPEMBlock(rootCA.cert))
+	otherPath := writeTestPEM(t, dir, "other.pem", certPEMBlock(newTestCA(t, "other CA").cert))
+
+	// newServer starts server, which presents the certificate issued by the intermediate CA along with chain
+	newServer := func(chain ...*x509.Certificate) *httptest.Server {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		tlsCert := tls.Certificate{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}
+		for _, cert := range chain {
+			tlsCert.Certificate = append(tlsCert.Certificate, cert.Raw)
+		}
+		server.TLS = &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+		server.StartTLS()
+		return server
+	}
+	// get sends request with client of the session of database without TLS configuration
+	get := func(t *testing.T, server *httptest.Server, rootCAs ...string) error {
+		db, err := Create(&config.ConnectionConfig{
+			ReplicaSet: []*config.Replica{{ID: "node1", Endpoint: server.URL}},
+			RootCAs:    rootCAs,
+			Logger:     createTestLogger(t),
+		})
+		require.NoError(t, err)
+		session := &dbSession{tlsConfig: db.(*bDB).tlsConfig}
+		res, err := session.newHTTPClient().Get(server.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	leafOnly := newServer()
+	defer leafOnly.Close()
+	withIntermediate := newServer(intermediateCert)
+	defer withIntermediate.Close()
+
+	t.Run("intermediate CA configured", func(t *testing.T) {
+		require.NoError(t, get(t, leafOnly, bundlePath))
+		require.NoError(t, get(t, withIntermediate, bundlePath))
+	})
+
+	t.Run("intermediate CA presented by server", func(t *testing.T) {
+		require.NoError(t, get(t, withIntermediate, rootPath))
+		err := get(t, leafOnly, rootPath)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "certificate signed by unknown authority")
+	})
+
+	t.Run("server certificate not issued by CA", func(t *testing.T) {
+		err := get(t, withIntermediate, otherPath)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "certificate signed by unknown authority")
+	})
+}
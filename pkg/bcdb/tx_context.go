@@ -8,10 +8,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
 	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/logger"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
 )
@@ -20,18 +23,30 @@ const (
 	contextTimeoutMargin = time.Second
 )
 
+// commonTxContext state shared by all transaction contexts. Exported methods of the contexts hold mu,
+// therefore a context is safe for concurrent use, while its operations are serialized
 type commonTxContext struct {
-	userID        string
-	signer        Signer
-	userCert      []byte
-	replicaSet    map[string]*url.URL
-	nodesCerts    map[string]*x509.Certificate
-	restClient    RestClient
-	txEnvelope    proto.Message
-	commitTimeout time.Duration
-	queryTimeout  time.Duration
-	txSpent       bool
-	logger        *logger.SugarLogger
+	mu              sync.Mutex
+	userID          string
+	signer          Signer
+	userCert        []byte
+	replicaSet      map[string]*url.URL
+	replicaSelector *replicaSelector
+	replicasOnce    sync.Once
+	discovery       *nodeDiscovery
+	nodesCerts      map[string]*x509.Certificate
+	nodesCertsCache *nodeCertsCache
+	restClient      RestClient
+	txID            string
+	txIDSupplied    bool
+	txEnvelope      proto.Message
+	commitTimeout   time.Duration
+	queryTimeout    time.Duration
+	receiptPolling  *config.ReceiptPollingConfig
+	ledgerVerifier  *ledgerVerifier
+	lifecycle       *sessionLifecycle
+	txSpent         bool
+	logger          *logger.SugarLogger
 }
 
 type txContext interface {
@@ -40,41 +55,104 @@ type txContext interface {
 	cleanCtx()
 }
 
-func (t *commonTxContext) commit(tx txContext, postEndpoint string, sync bool) (string, *types.TxReceipt, error) {
+func (t *commonTxContext) commit(ctx context.Context, tx txContext, postEndpoint string, sync bool) (string, *types.TxReceipt, error) {
 	if t.txSpent {
 		return "", nil, ErrTxSpent
 	}
+	if err := t.lifecycle.beginCommit(); err != nil {
+		return "", nil, err
+	}
+	defer t.lifecycle.endCommit()
 
-	replica := t.selectReplica()
-	postEndpointResolved := replica.ResolveReference(&url.URL{Path: postEndpoint})
-
-	txID, err := ComputeTxID(t.userCert)
+	txID, err := t.getOrComputeTxID()
 	if err != nil {
 		return "", nil, err
 	}
 
-	t.logger.Debugf("compose transaction enveloped with txID = %s", txID)
-	t.txEnvelope, err = tx.composeEnvelope(txID)
-	if err != nil {
-		t.logger.Errorf("failed to compose transaction envelope, due to %s", err)
+	// Envelope of the failed commit of transaction with ID set by the caller is resubmitted as is
+	if t.txEnvelope == nil {
+		t.logger.Debugf("compose transaction enveloped with txID = %s", txID)
+		t.txEnvelope, err = tx.composeEnvelope(txID)
+		if err != nil {
+			t.logger.Errorf("failed to compose transaction envelope, due to %s", err)
+			return txID, nil, err
+		}
+	}
+
+	txID, receipt, err := t.submit(ctx, tx, txID, postEndpoint, sync)
+	if err == nil || t.txSpent || t.txIDSupplied {
+		return txID, receipt, err
+	}
+	// Operations of the failed commit might have been submitted under the transaction ID, committing
+	// them again within the same context under new ID might apply them twice
+	t.txSpent = true
+	tx.cleanCtx()
+	if !isMaybeDeliveredError(err) {
 		return txID, nil, err
 	}
-	ctx := context.Background()
+	return txID, nil, errors.WithMessagef(err, "transaction [%s] context is spent, operations should be committed within new context", txID)
+}
+
+// submit submits composed transaction envelope to the replicas, the same envelope is resubmitted to
+// the next replica if transaction might have been delivered, but isn't committed yet
+func (t *commonTxContext) submit(ctx context.Context, tx txContext, txID, postEndpoint string, sync bool) (string, *types.TxReceipt, error) {
+	var err error
 	serverTimeout := time.Duration(0)
 	if sync {
 		serverTimeout = t.commitTimeout
 		contextTimeout := t.commitTimeout + contextTimeoutMargin
 		var cancelFnc context.CancelFunc
-		ctx, cancelFnc = context.WithTimeout(context.Background(), contextTimeout)
+		ctx, cancelFnc = context.WithTimeout(ctx, contextTimeout)
 		defer cancelFnc()
 	}
-	defer tx.cleanCtx()
 
-	response, err := t.restClient.Submit(ctx, postEndpointResolved.String(), t.txEnvelope, serverTimeout)
+	if t.txIDSupplied {
+		// Transaction with ID set by the caller might have been committed by other context already
+		receipt, receiptReplicaID, checkErr := t.queryTxReceipt(ctx, txID)
+		if checkErr == nil && receipt != nil {
+			t.logger.Debugf("transaction txID = %s is already committed", txID)
+			return t.committed(ctx, tx, txID, receiptReplicaID, receipt)
+		}
+		t.logger.Debugf("transaction txID = %s receipt isn't available, submitting, due to %s", txID, checkErr)
+	}
+
+	var response *http.Response
+	var replicaID string
+	err = ErrNoReplicaAvailable
+	for _, replica := range t.replicas().candidates() {
+		postEndpointResolved := replica.url.ResolveReference(&url.URL{Path: postEndpoint})
+		response, err = t.restClient.Submit(ctx, postEndpointResolved.String(), t.txEnvelope, serverTimeout)
+		if err == nil {
+			t.replicas().markSuccess(replica.id, 0)
+			replicaID = replica.id
+			break
+		}
+		t.logger.Errorf("failed to submit transaction txID = %s to replica %s, due to %s", txID, replica.id, err)
+		if !isConnectionError(err) && !isMaybeDeliveredError(err) {
+			return txID, nil, err
+		}
+		t.replicas().markFailure(replica.id, err)
+		if isDialError(err) {
+			continue
+		}
+		// Transaction might already reach the replica, the same envelope is resubmitted only if
+		// transaction wasn't committed yet. Missing receipt doesn't prove the transaction wasn't
+		// delivered, it might be pending, the server rejects resubmitted envelope as duplicate then
+		receipt, receiptReplicaID, checkErr := t.queryTxReceipt(ctx, txID)
+		if checkErr == nil && receipt != nil {
+			t.logger.Debugf("transaction txID = %s was committed before submission failure", txID)
+			return t.committed(ctx, tx, txID, receiptReplicaID, receipt)
+		}
+		if !errors.Is(checkErr, ErrNotFound) {
+			t.logger.Errorf("failed to check receipt of transaction txID = %s, due to %s", txID, checkErr)
+			return txID, nil, errors.WithMessagef(err, "transaction [%s] might have been submitted, its receipt isn't available", txID)
+		}
+		t.logger.Debugf("transaction txID = %s not found, resubmitting", txID)
+	}
 	if err != nil {
-		t.logger.Errorf("failed to submit transaction txID = %s, due to %s", txID, err)
 		return txID, nil, err
 	}
+	defer closeResponseBody(response)
 
 	if response.StatusCode != http.StatusOK {
 		var errMsg string
@@ -91,7 +169,23 @@ func (t *commonTxContext) commit(tx txContext, postEndpoint string, sync bool) (
 			}
 		}
 
-		return txID, nil, errors.Errorf("failed to submit transaction, server returned: status: %s, message: %s", response.Status, errMsg)
+		serverErr := &ServerError{
+			StatusCode: response.StatusCode,
+			Status:     response.Status,
+			Message:    errMsg,
+			ReplicaID:  replicaID,
+			TxID:       txID,
+		}
+		if errors.Is(serverErr, ErrDuplicateTxID) {
+			// Transaction with the same ID is either committed or still pending
+			receipt, receiptReplicaID, checkErr := t.queryTxReceipt(ctx, txID)
+			if checkErr == nil && receipt != nil {
+				t.logger.Debugf("transaction txID = %s is already committed", txID)
+				return t.committed(ctx, tx, txID, receiptReplicaID, receipt)
+			}
+			t.logger.Debugf("transaction txID = %s is pending, receipt isn't available, due to %s", txID, checkErr)
+		}
+		return txID, nil, serverErr
 	}
 
 	txResponseEnvelope := &types.ResponseEnvelope{}
@@ -108,6 +202,11 @@ func (t *commonTxContext) commit(tx txContext, postEndpoint string, sync bool) (
 		return txID, nil, err
 	}
 
+	if err = t.verifyResponseSignature(txResponseEnvelope, payload); err != nil {
+		t.logger.Errorf("failed to verify transaction response txID = %s, due to %s", txID, err)
+		return txID, nil, err
+	}
+
 	txResponse := &types.TxResponse{}
 	err = json.Unmarshal(payload.GetResponse(), txResponse)
 	if err != nil {
@@ -115,13 +214,108 @@ func (t *commonTxContext) commit(tx txContext, postEndpoint string, sync bool) (
 		return txID, nil, err
 	}
 
-	// TODO need to validate payload's signature
-	// r.Signature - the signature over payload
-	// payload.GetHeader().NodeID - the id of the node signed response
+	return t.committed(ctx, tx, txID, replicaID, txResponse.GetReceipt())
+}
 
+// committed marks the context spent once the transaction was accepted by the server and validates
+// the transaction receipt, if available, returned by the replica
+func (t *commonTxContext) committed(ctx context.Context, tx txContext, txID, replicaID string, receipt *types.TxReceipt) (string, *types.TxReceipt, error) {
 	t.txSpent = true
 	tx.cleanCtx()
-	return txID, txResponse.GetReceipt(), nil
+
+	if receipt != nil {
+		if err := t.verifyHeader(ctx, receipt.GetHeader()); err != nil {
+			t.logger.Errorf("failed to verify block header of transaction txID = %s receipt, due to %s", txID, err)
+			return txID, receipt, err
+		}
+		if err := validateReceipt(txID, receipt); err != nil {
+			t.logger.Debugf("transaction txID = %s is invalid, due to %s", txID, err)
+			invalidErr := &TxInvalidError{}
+			if errors.As(err, &invalidErr) {
+				invalidErr.ReplicaID = replicaID
+			}
+			return txID, receipt, err
+		}
+	}
+	return txID, receipt, nil
+}
+
+// SetTxID assigns ID to the transaction
+func (t *commonTxContext) SetTxID(txID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.txSpent {
+		return ErrTxSpent
+	}
+	if txID == "" {
+		return errors.New("transaction ID should not be empty")
+	}
+	t.txID = txID
+	t.txIDSupplied = true
+	return nil
+}
+
+// getOrComputeTxID returns the transaction ID assigned to the context, i.e. ID of loaded
+// transaction or ID set by the caller, or computes new random transaction ID
+func (t *commonTxContext) getOrComputeTxID() (string, error) {
+	if t.txID != "" {
+		return t.txID, nil
+	}
+	return ComputeTxID(t.userCert)
+}
+
+func (t *commonTxContext) commitFuture(ctx context.Context, tx txContext, postEndpoint string, sync bool) (TxFuture, error) {
+	txID, receipt, err := t.commit(ctx, tx, postEndpoint, sync)
+	if err != nil {
+		invalidErr := &TxInvalidError{}
+		if errors.As(err, &invalidErr) {
+			return newResolvedTxFuture(txID, receipt), nil
+		}
+		serverTimeout := &ServerTimeout{}
+		if !errors.As(err, &serverTimeout) && !errors.Is(err, ErrDuplicateTxID) {
+			return nil, err
+		}
+		t.logger.Debugf("transaction txID = %s is pending, polling transaction receipt, due to %s", txID, err)
+	} else if receipt != nil {
+		return newResolvedTxFuture(txID, receipt), nil
+	}
+
+	return newPollingTxFuture(txID, t.fetchTxReceipt, t.receiptPolling, t.lifecycle.done()), nil
+}
+
+// verifyHeader verifies block header against trusted ledger checkpoint, if ledger verification is enabled
+func (t *commonTxContext) verifyHeader(ctx context.Context, header *types.BlockHeader) error {
+	if t.ledgerVerifier == nil {
+		return nil
+	}
+	return t.ledgerVerifier.VerifyHeader(ctx, header)
+}
+
+func (t *commonTxContext) fetchTxReceipt(ctx context.Context, txID string) (*types.TxReceipt, error) {
+	l := &ledger{t}
+	return l.GetTransactionReceiptCtx(ctx, txID)
+}
+
+// queryTxReceipt returns transaction receipt along with the ID of the replica which returned it
+func (t *commonTxContext) queryTxReceipt(ctx context.Context, txID string) (*types.TxReceipt, string, error) {
+	res := &types.TxResponse{}
+	resEnv, err := t.handleSignedRequest(ctx, constants.URLForGetTransactionReceipt(txID), &types.GetTxReceiptQuery{
+		UserID: t.userID,
+		TxID:   txID,
+	}, res)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = t.verifyHeader(ctx, res.GetReceipt().GetHeader()); err != nil {
+		return nil, "", err
+	}
+
+	payload := &types.Payload{}
+	if err = json.Unmarshal(resEnv.GetPayload(), payload); err != nil {
+		return nil, "", err
+	}
+	return res.GetReceipt(), payload.GetHeader().GetNodeID(), nil
 }
 
 func (t *commonTxContext) abort(tx txContext) error {
@@ -134,32 +328,60 @@ func (t *commonTxContext) abort(tx txContext) error {
 	return nil
 }
 
-func (t *commonTxContext) selectReplica() *url.URL {
-	// Pick first replica to send request to
-	for _, replica := range t.replicaSet {
-		return replica
-	}
-	return nil
+// replicas returns replica selector used to pick replica to send request to
+func (t *commonTxContext) replicas() *replicaSelector {
+	t.replicasOnce.Do(func() {
+		if t.replicaSelector == nil {
+			// Selector wasn't provided by the session, fallback to the default selection strategy
+			t.replicaSelector, _ = newReplicaSelector(t.replicaSet, nil, t.logger)
+		}
+	})
+	return t.replicaSelector
 }
 
-func (t *commonTxContext) handleRequest(rawurl string, query, res proto.Message) error {
+func (t *commonTxContext) handleRequest(ctx context.Context, rawurl string, query, res proto.Message) error {
+	_, err := t.handleSignedRequest(ctx, rawurl, query, res)
+	return err
+}
+
+// handleSignedRequest executes the query and returns the signed response envelope, along with unmarshalled response
+func (t *commonTxContext) handleSignedRequest(ctx context.Context, rawurl string, query, res proto.Message) (*types.ResponseEnvelope, error) {
+	if err := t.lifecycle.check(); err != nil {
+		return nil, err
+	}
 	parsedURL, err := url.Parse(rawurl)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	restURL := t.selectReplica().ResolveReference(parsedURL).String()
-	ctx := context.Background()
 	if t.queryTimeout > 0 {
 		contextTimeout := t.queryTimeout
 		var cancelFnc context.CancelFunc
-		ctx, cancelFnc = context.WithTimeout(context.Background(), contextTimeout)
+		ctx, cancelFnc = context.WithTimeout(ctx, contextTimeout)
 		defer cancelFnc()
 	}
 
-	response, err := t.restClient.Query(ctx, restURL, query)
+	var response *http.Response
+	var replicaID string
+	err = ErrNoReplicaAvailable
+	for _, replica := range t.replicas().candidates() {
+		restURL := replica.url.ResolveReference(parsedURL).String()
+		start := time.Now()
+		response, err = t.restClient.Query(ctx, restURL, query)
+		if err == nil {
+			t.replicas().markSuccess(replica.id, time.Since(start))
+			replicaID = replica.id
+			break
+		}
+		if !isConnectionError(err) {
+			return nil, err
+		}
+		t.logger.Errorf("failed to query replica %s, due to %s", replica.id, err)
+		t.replicas().markFailure(replica.id, err)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer closeResponseBody(response)
 	if response.StatusCode != http.StatusOK {
 		var errMsg string
 		if response.Body != nil {
@@ -171,36 +393,56 @@ func (t *commonTxContext) handleRequest(rawurl string, query, res proto.Message)
 				errMsg = errRes.Error()
 			}
 		}
-		return errors.Errorf("error handling request, server returned: status: %s, message: %s", response.Status, errMsg)
+		return nil, &ServerError{
+			StatusCode: response.StatusCode,
+			Status:     response.Status,
+			Message:    errMsg,
+			ReplicaID:  replicaID,
+		}
 	}
 	r := &types.ResponseEnvelope{}
 	err = json.NewDecoder(response.Body).Decode(r)
 	if err != nil {
 		t.logger.Errorf("failed to decode json response, due to %s", err)
-		return err
+		return nil, err
 	}
 
 	payload := &types.Payload{}
 	err = json.Unmarshal(r.GetPayload(), payload)
 	if err != nil {
 		t.logger.Errorf("failed to unmarshal reponse payload, due to %s", err)
-		return err
+		return nil, err
 	}
 
-	// TODO need to validate payload's signature
-	// r.Signature - the signature over payload
-	// payload.GetHeader().NodeID - the id of the node signed response
+	if err = t.verifyResponseSignature(r, payload); err != nil {
+		t.logger.Errorf("failed to verify response, due to %s", err)
+		return nil, err
+	}
 
 	err = json.Unmarshal(payload.GetResponse(), res)
 	if err != nil {
 		t.logger.Errorf("failed to unmarshal response, due to %s", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	return r, nil
+}
+
+// verifyResponseSignature validates signature of the response with certificates of the nodes, known to the
+// context. Verification failure might be caused by stale certificates, e.g. node certificate was renewed,
+// therefore session's certificates cache is invalidated and next context downloads them again
+func (t *commonTxContext) verifyResponseSignature(resEnv *types.ResponseEnvelope, payload *types.Payload) error {
+	err := verifyResponseSignature(t.nodesCerts, resEnv, payload)
+	if err != nil && t.nodesCertsCache != nil {
+		t.nodesCertsCache.invalidate()
+	}
+	return err
 }
 
 func (t *commonTxContext) TxEnvelope() (proto.Message, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	if t.txEnvelope == nil {
 		return nil, ErrTxNotFinalized
 	}
@@ -4,18 +4,28 @@ package bcdb
 
 import (
 	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/IBM-Blockchain/bcdb-sdk/pkg/bcdb/mocks"
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -45,6 +55,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: asyncSubmit,
 						resp:    okResponseAsync(),
@@ -66,6 +77,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: asyncSubmit,
 						resp:    serverBadRequestResponse(),
@@ -88,6 +100,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: syncSubmit,
 						resp:    okResponse(),
@@ -111,6 +124,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: syncSubmit,
 						resp:    serverTimeoutResponse(),
@@ -135,6 +149,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: submitErr,
 						resp:    nil,
@@ -157,6 +172,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: asyncSubmit,
 						resp:    okResponseAsync(),
@@ -179,6 +195,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: syncSubmit,
 						resp:    okResponse(),
@@ -203,6 +220,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: syncSubmit,
 						resp:    serverTimeoutResponse(),
@@ -228,6 +246,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: asyncSubmit,
 						resp:    okResponseAsync(),
@@ -249,6 +268,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: syncSubmit,
 						resp:    okResponse(),
@@ -272,6 +292,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: syncSubmit,
 						resp:    serverTimeoutResponse(),
@@ -296,6 +317,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: asyncSubmit,
 						resp:    okResponseAsync(),
@@ -317,6 +339,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: syncSubmit,
 						resp:    okResponse(),
@@ -340,6 +363,7 @@ func TestTxCommit(t *testing.T) {
 							Path: "http://localhost:8888",
 						},
 					},
+					nodesCerts: testNodesCerts(),
 					restClient: NewRestClient("testUser", &mockHttpClient{
 						process: syncSubmit,
 						resp:    serverTimeoutResponse(),
@@ -401,6 +425,7 @@ func TestTxQuery(t *testing.T) {
 						Path: "http://localhost:8888",
 					},
 				},
+				nodesCerts: testNodesCerts(),
 				restClient: NewRestClient("testUser", &mockHttpClient{
 					process: querySleep100,
 					resp:    okDataQueryResponse(),
@@ -421,6 +446,7 @@ func TestTxQuery(t *testing.T) {
 						Path: "http://localhost:8888",
 					},
 				},
+				nodesCerts: testNodesCerts(),
 				restClient: NewRestClient("testUser", &mockHttpClient{
 					process: querySleep100,
 					resp:    okDataQueryResponse(),
@@ -442,6 +468,7 @@ func TestTxQuery(t *testing.T) {
 						Path: "http://localhost:8888",
 					},
 				},
+				nodesCerts: testNodesCerts(),
 				restClient: NewRestClient("testUser", &mockHttpClient{
 					process: querySleep10,
 					resp:    okDataQueryResponse(),
@@ -464,7 +491,7 @@ func TestTxQuery(t *testing.T) {
 				DBName: "bdb",
 				Key:    "key1",
 			}
-			err = tt.txCtx.handleRequest(constants.URLForGetData("bdb", "key1"), req, res)
+			err = tt.txCtx.handleRequest(context.Background(), constants.URLForGetData("bdb", "key1"), req, res)
 			if tt.wantErr {
 				require.Error(t, err)
 				require.Contains(t, err.Error(), tt.errMsg)
@@ -476,6 +503,537 @@ func TestTxQuery(t *testing.T) {
 
 }
 
+func TestTxCtxCanceled(t *testing.T) {
+	emptySigner := &mocks.Signer{}
+	emptySigner.On("Sign", mock.Anything).Return([]byte{1}, nil)
+	logger := createTestLogger(t)
+
+	newCommonCtx := func(resp *http.Response) *commonTxContext {
+		return &commonTxContext{
+			userID:   "testUser",
+			signer:   emptySigner,
+			userCert: []byte{1, 2, 3},
+			replicaSet: map[string]*url.URL{
+				"node1": {
+					Path: "http://localhost:8888",
+				},
+			},
+			nodesCerts: testNodesCerts(),
+			restClient: NewRestClient("testUser", &mockHttpClient{
+				process: requestCtxErr,
+				resp:    resp,
+			}, emptySigner),
+			commitTimeout: time.Second,
+			logger:        logger,
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dataTx := &dataTxContext{
+		commonTxContext: newCommonCtx(okDataQueryResponse()),
+		operations:      map[string]*dbOperations{},
+	}
+	_, _, err := dataTx.GetCtx(ctx, "bdb", "key1")
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	value, _, err := dataTx.GetCtx(context.Background(), "bdb", "key1")
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+
+	dataTx = &dataTxContext{
+		commonTxContext: newCommonCtx(okResponse()),
+		operations:      map[string]*dbOperations{},
+	}
+	require.NoError(t, dataTx.Put("bdb", "key1", []byte{1}, nil))
+	_, _, err = dataTx.CommitCtx(ctx, true)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestTxCommit_ResubmitAfterTransportError(t *testing.T) {
+	emptySigner := &mocks.Signer{}
+	emptySigner.On("Sign", mock.Anything).Return([]byte{1}, nil)
+	logger := createTestLogger(t)
+
+	newDataTx := func(receiptQuery func() (*http.Response, error)) (*dataTxContext, map[string][]string) {
+		submissions := map[string][]string{}
+		httpClient := &mockHttpClient{
+			process: func(req *http.Request, resp *http.Response) (*http.Response, error) {
+				if req.Method == http.MethodGet {
+					return receiptQuery()
+				}
+				env := &types.DataTxEnvelope{}
+				require.NoError(t, json.NewDecoder(req.Body).Decode(env))
+				submissions[req.URL.Host] = append(submissions[req.URL.Host], env.GetPayload().GetTxID())
+				if req.URL.Host == "node1:6001" {
+					return nil, &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+				}
+				return okResponse(), nil
+			},
+		}
+
+		replicaSet := testReplicaSet()
+		delete(replicaSet, "node3")
+		selector, err := newReplicaSelector(replicaSet, nil, logger)
+		require.NoError(t, err)
+		selector.probe = func(*url.URL, time.Duration) error {
+			return errors.New("connection refused")
+		}
+
+		dataTx := &dataTxContext{
+			commonTxContext: &commonTxContext{
+				userID:          "testUser",
+				signer:          emptySigner,
+				userCert:        []byte{1, 2, 3},
+				replicaSet:      replicaSet,
+				replicaSelector: selector,
+				nodesCerts:      testNodesCerts(),
+				restClient:      NewRestClient("testUser", httpClient, emptySigner),
+				logger:          logger,
+			},
+			operations: make(map[string]*dbOperations),
+		}
+		require.NoError(t, dataTx.Put("bdb", "key1", []byte{1}, nil))
+		return dataTx, submissions
+	}
+
+	txID, err := ComputeDeterministicTxID([]byte{1, 2, 3}, []byte("payment-1"))
+	require.NoError(t, err)
+	otherTxID, err := ComputeDeterministicTxID([]byte{1, 2, 3}, []byte("payment-2"))
+	require.NoError(t, err)
+	require.NotEqual(t, txID, otherTxID)
+	sameTxID, err := ComputeDeterministicTxID([]byte{1, 2, 3}, []byte("payment-1"))
+	require.NoError(t, err)
+	require.Equal(t, txID, sameTxID)
+
+	t.Run("committed before transport error", func(t *testing.T) {
+		receiptQueries := 0
+		dataTx, submissions := newDataTx(func() (*http.Response, error) {
+			// transaction isn't committed yet while checked before submission
+			receiptQueries++
+			if receiptQueries == 1 {
+				return serverNotFoundResponse(), nil
+			}
+			return okResponse(), nil
+		})
+		require.NoError(t, dataTx.SetTxID(txID))
+
+		committedTxID, receipt, err := dataTx.Commit(true)
+		require.NoError(t, err)
+		require.Equal(t, txID, committedTxID)
+		require.NotNil(t, receipt)
+		require.Equal(t, map[string][]string{"node1:6001": {txID}}, submissions)
+
+		_, _, err = dataTx.Commit(true)
+		require.Equal(t, ErrTxSpent, err)
+		require.Equal(t, ErrTxSpent, dataTx.SetTxID(otherTxID))
+	})
+
+	t.Run("committed by other context", func(t *testing.T) {
+		dataTx, submissions := newDataTx(func() (*http.Response, error) {
+			return okResponse(), nil
+		})
+		require.NoError(t, dataTx.SetTxID(txID))
+
+		committedTxID, receipt, err := dataTx.Commit(true)
+		require.NoError(t, err)
+		require.Equal(t, txID, committedTxID)
+		require.NotNil(t, receipt)
+		require.Empty(t, submissions)
+	})
+
+	t.Run("not committed, same envelope resubmitted", func(t *testing.T) {
+		dataTx, submissions := newDataTx(func() (*http.Response, error) {
+			return serverNotFoundResponse(), nil
+		})
+		require.NoError(t, dataTx.SetTxID(txID))
+
+		committedTxID, receipt, err := dataTx.Commit(true)
+		require.NoError(t, err)
+		require.Equal(t, txID, committedTxID)
+		require.NotNil(t, receipt)
+		require.Equal(t, map[string][]string{"node1:6001": {txID}, "node2:6001": {txID}}, submissions)
+	})
+
+	t.Run("receipt unavailable", func(t *testing.T) {
+		dataTx, submissions := newDataTx(func() (*http.Response, error) {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		})
+
+		committedTxID, receipt, err := dataTx.Commit(true)
+		require.NotEmpty(t, committedTxID)
+		require.Nil(t, receipt)
+		require.Contains(t, err.Error(), "might have been submitted, its receipt isn't available")
+		opErr := &net.OpError{}
+		require.True(t, errors.As(err, &opErr))
+		require.Equal(t, "read", opErr.Op)
+		require.Equal(t, map[string][]string{"node1:6001": {committedTxID}}, submissions)
+	})
+
+	t.Run("empty tx ID", func(t *testing.T) {
+		dataTx, _ := newDataTx(nil)
+		require.EqualError(t, dataTx.SetTxID(""), "transaction ID should not be empty")
+		_, err := ComputeDeterministicTxID([]byte{1, 2, 3}, nil)
+		require.EqualError(t, err, "idempotency key should not be empty")
+	})
+}
+
+// submitAction how the replica handles transaction submission, see droppingLedger
+type submitAction int
+
+const (
+	// submitCommit transaction committed, receipt returned
+	submitCommit submitAction = iota
+	// submitDrop connection dropped once the request is read, transaction isn't committed
+	submitDrop
+	// submitCommitAndDrop connection dropped once the request is read, transaction committed
+	submitCommitAndDrop
+	// submitRejectDuplicate transaction rejected, since transaction with the same ID is pending
+	submitRejectDuplicate
+)
+
+// droppingLedger ledger shared by the replicas, which handle submissions according to the actions,
+// transactions submitted after the actions are exhausted are committed
+type droppingLedger struct {
+	mu        sync.Mutex
+	actions   []submitAction
+	envelopes []*types.DataTxEnvelope
+	committed map[string]bool
+}
+
+func (l *droppingLedger) commit(txID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.committed[txID] = true
+}
+
+func (l *droppingLedger) submitted() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var txIDs []string
+	for _, env := range l.envelopes {
+		txIDs = append(txIDs, env.GetPayload().GetTxID())
+	}
+	return txIDs
+}
+
+func (l *droppingLedger) submittedEnvelopes() []*types.DataTxEnvelope {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]*types.DataTxEnvelope(nil), l.envelopes...)
+}
+
+func (l *droppingLedger) handler(t *testing.T, node *testNode, clusterConfig *types.GetConfigResponse) http.Handler {
+	signed := signedResponseHandler(t, func(r *http.Request) (proto.Message, *testNode) {
+		switch {
+		case r.URL.Path == constants.URLForGetConfig():
+			return clusterConfig, node
+		case r.URL.Path == constants.PostDataTx || strings.HasPrefix(r.URL.Path, constants.URLForGetTransactionReceipt("")):
+			return &types.TxResponse{Receipt: receiptWithFlag(types.Flag_VALID, "")}, node
+		default:
+			return nil, nil
+		}
+	})
+	writeErr := func(w http.ResponseWriter, status int, msg string) {
+		w.WriteHeader(status)
+		require.NoError(t, json.NewEncoder(w).Encode(&types.HttpResponseErr{ErrMsg: msg}))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, constants.URLForGetTransactionReceipt("")) {
+			txID := strings.TrimPrefix(r.URL.Path, constants.URLForGetTransactionReceipt(""))
+			l.mu.Lock()
+			committed := l.committed[txID]
+			l.mu.Unlock()
+			if !committed {
+				writeErr(w, http.StatusNotFound, "txID not found: "+txID)
+				return
+			}
+		}
+		if r.URL.Path != constants.PostDataTx {
+			signed.ServeHTTP(w, r)
+			return
+		}
+
+		env := &types.DataTxEnvelope{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(env))
+		txID := env.GetPayload().GetTxID()
+		l.mu.Lock()
+		action := submitCommit
+		if len(l.envelopes) < len(l.actions) {
+			action = l.actions[len(l.envelopes)]
+		}
+		l.envelopes = append(l.envelopes, env)
+		if action == submitCommit || action == submitCommitAndDrop {
+			l.committed[txID] = true
+		}
+		l.mu.Unlock()
+
+		switch action {
+		case submitDrop, submitCommitAndDrop:
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+		case submitRejectDuplicate:
+			writeErr(w, http.StatusBadRequest, "the transaction has a duplicate txID ["+txID+"]")
+		default:
+			signed.ServeHTTP(w, r)
+		}
+	})
+}
+
+func TestTxCommit_ConnectionDroppedAfterRequest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dropped-connection")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "CA")
+	caPath := writeTestPEM(t, dir, "CA.pem", certPEMBlock(ca.cert))
+	clusterConfig := &types.GetConfigResponse{Config: &types.ClusterConfig{}}
+	var nodes []*testNode
+	for _, id := range []string{"node1", "node2"} {
+		cert, key := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: id}})
+		nodes = append(nodes, &testNode{id: id, cert: cert, key: key})
+		clusterConfig.Config.Nodes = append(clusterConfig.Config.Nodes, &types.NodeConfig{ID: id, Certificate: cert.Raw})
+	}
+
+	newSession := func(actions ...submitAction) (DBSession, *droppingLedger) {
+		l := &droppingLedger{actions: actions, committed: make(map[string]bool)}
+		var replicas []*config.Replica
+		for _, node := range nodes {
+			server := httptest.NewServer(l.handler(t, node, clusterConfig))
+			t.Cleanup(server.Close)
+			replicas = append(replicas, &config.Replica{ID: node.id, Endpoint: server.URL})
+		}
+
+		db, err := Create(&config.ConnectionConfig{
+			ReplicaSet: replicas,
+			RootCAs:    []string{caPath},
+			Logger:     createTestLogger(t),
+		})
+		require.NoError(t, err)
+		sessionConfig := testSessionConfig(t, ca)
+		sessionConfig.TxTimeout = time.Second
+		sessionConfig.ReceiptPolling = &config.ReceiptPollingConfig{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+		session, err := db.Session(sessionConfig)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			session.Close()
+		})
+		return session, l
+	}
+	newDataTx := func(session DBSession) DataTxContext {
+		tx, err := session.DataTx()
+		require.NoError(t, err)
+		require.NoError(t, tx.Put("bdb", "key1", []byte{1}, nil))
+		return tx
+	}
+
+	t.Run("dropped before commit, same envelope resubmitted", func(t *testing.T) {
+		session, l := newSession(submitDrop)
+		txID, receipt, err := newDataTx(session).Commit(true)
+		require.NoError(t, err)
+		require.NotNil(t, receipt)
+		require.Equal(t, []string{txID, txID}, l.submitted())
+	})
+
+	t.Run("dropped after commit", func(t *testing.T) {
+		session, l := newSession(submitCommitAndDrop)
+		txID, receipt, err := newDataTx(session).Commit(true)
+		require.NoError(t, err)
+		require.NotNil(t, receipt)
+		require.Equal(t, []string{txID}, l.submitted())
+	})
+
+	t.Run("dropped while pending, resubmission rejected as duplicate", func(t *testing.T) {
+		session, l := newSession(submitDrop, submitRejectDuplicate, submitDrop, submitRejectDuplicate)
+		txID, receipt, err := newDataTx(session).Commit(true)
+		require.Nil(t, receipt)
+		require.True(t, errors.Is(err, ErrDuplicateTxID))
+		serverErr := &ServerError{}
+		require.True(t, errors.As(err, &serverErr))
+		require.Equal(t, txID, serverErr.TxID)
+		require.Equal(t, []string{txID, txID}, l.submitted())
+
+		// receipt is polled once the transaction is committed
+		f, err := newDataTx(session).CommitFuture(context.Background(), true)
+		require.NoError(t, err)
+		_, err = f.Receipt()
+		require.Equal(t, ErrTxPending, err)
+		l.commit(f.TxID())
+		receipt, err = f.Wait(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, receipt)
+		require.Len(t, l.submitted(), 4)
+	})
+
+	t.Run("caller supplied ID committed by other context", func(t *testing.T) {
+		session, l := newSession()
+		txID, err := ComputeDeterministicTxID([]byte{1, 2, 3}, []byte("payment-1"))
+		require.NoError(t, err)
+		l.commit(txID)
+
+		tx := newDataTx(session)
+		require.NoError(t, tx.SetTxID(txID))
+		committedTxID, receipt, err := tx.Commit(true)
+		require.NoError(t, err)
+		require.Equal(t, txID, committedTxID)
+		require.NotNil(t, receipt)
+		require.Empty(t, l.submitted())
+	})
+
+	t.Run("caller supplied ID recommitted after failure", func(t *testing.T) {
+		session, l := newSession(submitDrop, submitDrop)
+		txID, err := ComputeDeterministicTxID([]byte{1, 2, 3}, []byte("payment-3"))
+		require.NoError(t, err)
+
+		tx := newDataTx(session)
+		require.NoError(t, tx.SetTxID(txID))
+		_, receipt, err := tx.Commit(true)
+		require.Error(t, err)
+		require.Nil(t, receipt)
+		require.Equal(t, []string{txID, txID}, l.submitted())
+
+		// the same envelope is resubmitted, operations added after the failure aren't included
+		require.NoError(t, tx.Put("bdb", "key2", []byte{2}, nil))
+		committedTxID, receipt, err := tx.Commit(true)
+		require.NoError(t, err)
+		require.Equal(t, txID, committedTxID)
+		require.NotNil(t, receipt)
+		envelopes := l.submittedEnvelopes()
+		require.Len(t, envelopes, 3)
+		require.True(t, proto.Equal(envelopes[0], envelopes[2]))
+		dbOps := envelopes[2].GetPayload().GetDBOperations()
+		require.Len(t, dbOps, 1)
+		require.Len(t, dbOps[0].GetDataWrites(), 1)
+		require.Equal(t, "key1", dbOps[0].GetDataWrites()[0].GetKey())
+
+		_, _, err = tx.Commit(true)
+		require.Equal(t, ErrTxSpent, err)
+	})
+
+	t.Run("random ID context spent after failure", func(t *testing.T) {
+		session, l := newSession(submitDrop, submitDrop)
+		tx := newDataTx(session)
+		txID, receipt, err := tx.Commit(true)
+		require.Nil(t, receipt)
+		require.Contains(t, err.Error(), "transaction ["+txID+"] context is spent, operations should be committed within new context")
+		require.Equal(t, []string{txID, txID}, l.submitted())
+
+		_, _, err = tx.Commit(true)
+		require.Equal(t, ErrTxSpent, err)
+		require.Equal(t, ErrTxSpent, tx.Put("bdb", "key2", []byte{2}, nil))
+		require.Len(t, l.submitted(), 2)
+	})
+
+	t.Run("caller supplied ID pending", func(t *testing.T) {
+		session, l := newSession(submitRejectDuplicate)
+		txID, err := ComputeDeterministicTxID([]byte{1, 2, 3}, []byte("payment-2"))
+		require.NoError(t, err)
+
+		tx := newDataTx(session)
+		require.NoError(t, tx.SetTxID(txID))
+		f, err := tx.CommitFuture(context.Background(), false)
+		require.NoError(t, err)
+		l.commit(txID)
+		receipt, err := f.Wait(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, receipt)
+		require.Equal(t, []string{txID}, l.submitted())
+	})
+}
+
+func TestDataTxContext_ReadYourOwnWrites(t *testing.T) {
+	emptySigner := &mocks.Signer{}
+	emptySigner.On("Sign", mock.Anything).Return([]byte{1}, nil)
+	logger := createTestLogger(t)
+
+	var queries int
+	newDataTx := func(strictSnapshot bool) *dataTxContext {
+		return &dataTxContext{
+			commonTxContext: &commonTxContext{
+				userID:   "testUser",
+				signer:   emptySigner,
+				userCert: []byte{1, 2, 3},
+				replicaSet: map[string]*url.URL{
+					"node1": {
+						Path: "http://localhost:8888",
+					},
+				},
+				nodesCerts: testNodesCerts(),
+				restClient: NewRestClient("testUser", &mockHttpClient{
+					process: func(req *http.Request, resp *http.Response) (*http.Response, error) {
+						queries++
+						return okDataQueryResponse(), nil
+					},
+				}, emptySigner),
+				logger: logger,
+			},
+			operations:     map[string]*dbOperations{},
+			strictSnapshot: strictSnapshot,
+		}
+	}
+
+	t.Run("pending values", func(t *testing.T) {
+		queries = 0
+		dataTx := newDataTx(false)
+		acl := &types.AccessControl{
+			ReadUsers: UsersMap("alice"),
+		}
+		require.NoError(t, dataTx.Put("bdb", "key1", []byte{2}, acl))
+		value, meta, err := dataTx.Get("bdb", "key1")
+		require.NoError(t, err)
+		require.Equal(t, []byte{2}, value)
+		require.Equal(t, acl, meta.GetAccessControl())
+		require.Nil(t, meta.GetVersion())
+
+		require.NoError(t, dataTx.Delete("bdb", "key1"))
+		value, meta, err = dataTx.Get("bdb", "key1")
+		require.NoError(t, err)
+		require.Nil(t, value)
+		require.Nil(t, meta)
+
+		// Pending values are not added to the read set
+		require.Equal(t, 0, queries)
+		require.Empty(t, dataTx.operations["bdb"].dataReads)
+
+		// Read of key not changed by transaction goes to the server
+		value, _, err = dataTx.Get("bdb", "key2")
+		require.NoError(t, err)
+		require.Equal(t, []byte{1}, value)
+		require.Equal(t, 1, queries)
+		require.Len(t, dataTx.operations["bdb"].dataReads, 1)
+
+		// Read value is overridden by pending write
+		require.NoError(t, dataTx.Put("bdb", "key2", []byte{3}, nil))
+		value, _, err = dataTx.Get("bdb", "key2")
+		require.NoError(t, err)
+		require.Equal(t, []byte{3}, value)
+		require.Equal(t, 1, queries)
+	})
+
+	t.Run("strict snapshot", func(t *testing.T) {
+		queries = 0
+		dataTx := newDataTx(true)
+		require.NoError(t, dataTx.Put("bdb", "key1", []byte{2}, nil))
+		value, meta, err := dataTx.Get("bdb", "key1")
+		require.NoError(t, err)
+		require.Equal(t, []byte{1}, value)
+		require.NotNil(t, meta)
+
+		require.NoError(t, dataTx.Delete("bdb", "key1"))
+		value, _, err = dataTx.Get("bdb", "key1")
+		require.NoError(t, err)
+		require.Equal(t, []byte{1}, value)
+		require.Equal(t, 1, queries)
+		require.Len(t, dataTx.operations["bdb"].dataReads, 1)
+	})
+}
+
 func okResponse() *http.Response {
 	okResp := &types.ResponseEnvelope{
 		Payload: MarshalOrPanic(&types.Payload{
@@ -488,12 +1046,21 @@ func okResponse() *http.Response {
 						BaseHeader: &types.BlockHeaderBase{
 							Number: 1,
 						},
+						ValidationInfo: []*types.ValidationInfo{
+							{
+								Flag: types.Flag_VALID,
+							},
+							{
+								Flag: types.Flag_VALID,
+							},
+						},
 					},
 					TxIndex: 1,
 				},
 			}),
 		}),
 	}
+	okResp.Signature = testNodeIdentity.sign(okResp.Payload)
 	okPbJson, _ := json.Marshal(okResp)
 	okRespReader := ioutil.NopCloser(bytes.NewReader([]byte(okPbJson)))
 	return &http.Response{
@@ -512,6 +1079,7 @@ func okResponseAsync() *http.Response {
 			Response: MarshalOrPanic(&types.TxResponse{}),
 		}),
 	}
+	okResp.Signature = testNodeIdentity.sign(okResp.Payload)
 	okPbJson, _ := json.Marshal(okResp)
 	okRespReader := ioutil.NopCloser(bytes.NewReader([]byte(okPbJson)))
 	return &http.Response{
@@ -534,6 +1102,7 @@ func okDataQueryResponse() *http.Response {
 		}),
 	}
 
+	okResp.Signature = testNodeIdentity.sign(okResp.Payload)
 	okPbJson, _ := json.Marshal(okResp)
 	okRespReader := ioutil.NopCloser(bytes.NewReader([]byte(okPbJson)))
 	return &http.Response{
@@ -556,6 +1125,19 @@ func serverTimeoutResponse() *http.Response {
 	}
 }
 
+func serverNotFoundResponse() *http.Response {
+	errResp := &types.HttpResponseErr{
+		ErrMsg: "not found",
+	}
+	errPbJson, _ := json.Marshal(errResp)
+	errRespReader := ioutil.NopCloser(bytes.NewReader([]byte(errPbJson)))
+	return &http.Response{
+		StatusCode: http.StatusNotFound,
+		Status:     http.StatusText(http.StatusNotFound),
+		Body:       errRespReader,
+	}
+}
+
 func serverBadRequestResponse() *http.Response {
 	errResp := &types.HttpResponseErr{
 		ErrMsg: "Bad request error",
@@ -608,6 +1190,13 @@ func submitErr(_ *http.Request, resp *http.Response) (*http.Response, error) {
 	return nil, errors.New("submit error")
 }
 
+func requestCtxErr(req *http.Request, resp *http.Response) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 func querySleep100(req *http.Request, resp *http.Response) (*http.Response, error) {
 	time.Sleep(time.Millisecond * 100)
 	ctx := req.Context()
@@ -0,0 +1,170 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultReceiptPollingInitialInterval = 100 * time.Millisecond
+	defaultReceiptPollingMaxInterval     = 2 * time.Second
+	defaultReceiptPollingTimeout         = time.Minute
+)
+
+var (
+	// ErrTxPending returned by TxFuture.Receipt if transaction outcome is not known yet
+	ErrTxPending = errors.New("transaction is pending, receipt not available yet")
+	// ErrReceiptTimeout returned by TxFuture if transaction receipt wasn't obtained within polling timeout
+	ErrReceiptTimeout = errors.New("timeout occurred while waiting for transaction receipt")
+)
+
+// TxFuture tracks transaction submitted to the server until its receipt is available
+type TxFuture interface {
+	// TxID returns ID of the tracked transaction
+	TxID() string
+	// Wait blocks until transaction receipt is available or ctx is done.
+	// If transaction was invalidated by the server, receipt returned along with *TxInvalidError
+	Wait(ctx context.Context) (*types.TxReceipt, error)
+	// Done returns channel closed once transaction outcome is known
+	Done() <-chan struct{}
+	// Receipt returns transaction receipt without blocking, ErrTxPending returned if
+	// transaction outcome is not known yet
+	Receipt() (*types.TxReceipt, error)
+}
+
+type txFuture struct {
+	txID    string
+	done    chan struct{}
+	receipt *types.TxReceipt
+	err     error
+}
+
+// newResolvedTxFuture returns future of the transaction, which receipt is already known
+func newResolvedTxFuture(txID string, receipt *types.TxReceipt) *txFuture {
+	f := &txFuture{
+		txID:    txID,
+		done:    make(chan struct{}),
+		receipt: receipt,
+		err:     validateReceipt(txID, receipt),
+	}
+	close(f.done)
+	return f
+}
+
+// newPollingTxFuture returns future, which polls transaction receipt in the background using fetch,
+// polling interval doubles after each unsuccessful attempt. Polling is stopped once stop is closed
+func newPollingTxFuture(txID string, fetch func(ctx context.Context, txID string) (*types.TxReceipt, error), cfg *config.ReceiptPollingConfig, stop <-chan struct{}) *txFuture {
+	f := &txFuture{
+		txID: txID,
+		done: make(chan struct{}),
+	}
+
+	initialInterval := defaultReceiptPollingInitialInterval
+	maxInterval := defaultReceiptPollingMaxInterval
+	timeout := defaultReceiptPollingTimeout
+	if cfg != nil {
+		if cfg.InitialInterval > 0 {
+			initialInterval = cfg.InitialInterval
+		}
+		if cfg.MaxInterval > 0 {
+			maxInterval = cfg.MaxInterval
+		}
+		if cfg.Timeout > 0 {
+			timeout = cfg.Timeout
+		}
+	}
+
+	go f.poll(fetch, initialInterval, maxInterval, timeout, stop)
+	return f
+}
+
+func (f *txFuture) poll(fetch func(ctx context.Context, txID string) (*types.TxReceipt, error), interval, maxInterval, timeout time.Duration, stop <-chan struct{}) {
+	defer close(f.done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-stop:
+			f.err = errors.Wrapf(ErrSessionClosed, "transaction [%s], receipt polling stopped", f.txID)
+			return
+		case <-ctx.Done():
+			if lastErr != nil {
+				f.err = errors.Wrapf(ErrReceiptTimeout, "transaction [%s], last error: %s", f.txID, lastErr)
+			} else {
+				f.err = errors.Wrapf(ErrReceiptTimeout, "transaction [%s]", f.txID)
+			}
+			return
+		case <-timer.C:
+		}
+
+		receipt, err := fetch(ctx, f.txID)
+		if err == nil && receipt != nil {
+			f.receipt = receipt
+			f.err = validateReceipt(f.txID, receipt)
+			return
+		}
+		if isPermanentError(err) {
+			f.err = err
+			return
+		}
+		lastErr = err
+
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+		timer.Reset(interval)
+	}
+}
+
+func (f *txFuture) TxID() string {
+	return f.txID
+}
+
+func (f *txFuture) Wait(ctx context.Context) (*types.TxReceipt, error) {
+	select {
+	case <-f.done:
+		return f.receipt, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *txFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *txFuture) Receipt() (*types.TxReceipt, error) {
+	select {
+	case <-f.done:
+		return f.receipt, f.err
+	default:
+		return nil, ErrTxPending
+	}
+}
+
+// whenDone calls callback with the transaction outcome once it is known, immediately if the future is
+// already resolved, otherwise from the background goroutine
+func whenDone(f TxFuture, callback func(receipt *types.TxReceipt, err error)) {
+	select {
+	case <-f.Done():
+		callback(f.Receipt())
+	default:
+		go func() {
+			<-f.Done()
+			callback(f.Receipt())
+		}()
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func receiptWithFlag(flag types.Flag, reason string) *types.TxReceipt {
+	return &types.TxReceipt{
+		Header: &types.BlockHeader{
+			BaseHeader: &types.BlockHeaderBase{
+				Number: 5,
+			},
+			ValidationInfo: []*types.ValidationInfo{
+				{
+					Flag:            flag,
+					ReasonIfInvalid: reason,
+				},
+			},
+		},
+		TxIndex: 0,
+	}
+}
+
+func TestTxFuture_Resolved(t *testing.T) {
+	f := newResolvedTxFuture("tx1", receiptWithFlag(types.Flag_VALID, ""))
+	require.Equal(t, "tx1", f.TxID())
+
+	select {
+	case <-f.Done():
+	default:
+		require.Fail(t, "resolved future must be done")
+	}
+	receipt, err := f.Receipt()
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), receipt.GetHeader().GetBaseHeader().GetNumber())
+
+	f = newResolvedTxFuture("tx2", receiptWithFlag(types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE, "mvcc conflict"))
+	receipt, err = f.Wait(context.Background())
+	require.NotNil(t, receipt)
+	invalidErr := &TxInvalidError{}
+	require.True(t, errors.As(err, &invalidErr))
+	require.Equal(t, "tx2", invalidErr.TxID)
+	require.Equal(t, types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE, invalidErr.Flag)
+	require.Equal(t, "mvcc conflict", invalidErr.Reason)
+}
+
+func TestTxFuture_Polling(t *testing.T) {
+	pollingCfg := &config.ReceiptPollingConfig{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     5 * time.Millisecond,
+		Timeout:         time.Second,
+	}
+
+	t.Run("receipt available after few attempts", func(t *testing.T) {
+		var attempts int32
+		release := make(chan struct{})
+		fetch := func(ctx context.Context, txID string) (*types.TxReceipt, error) {
+			<-release
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				return nil, errors.New("not found")
+			}
+			return receiptWithFlag(types.Flag_VALID, ""), nil
+		}
+
+		f := newPollingTxFuture("tx1", fetch, pollingCfg, nil)
+		receipt, err := f.Receipt()
+		require.Equal(t, ErrTxPending, err)
+		require.Nil(t, receipt)
+
+		close(release)
+		receipt, err = f.Wait(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, receipt)
+		require.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+		receipt, err = f.Receipt()
+		require.NoError(t, err)
+		require.NotNil(t, receipt)
+	})
+
+	t.Run("invalid transaction", func(t *testing.T) {
+		fetch := func(ctx context.Context, txID string) (*types.TxReceipt, error) {
+			return receiptWithFlag(types.Flag_INVALID_NO_PERMISSION, "no permission"), nil
+		}
+
+		f := newPollingTxFuture("tx1", fetch, pollingCfg, nil)
+		<-f.Done()
+		receipt, err := f.Receipt()
+		require.NotNil(t, receipt)
+		invalidErr := &TxInvalidError{}
+		require.True(t, errors.As(err, &invalidErr))
+		require.Equal(t, types.Flag_INVALID_NO_PERMISSION, invalidErr.Flag)
+	})
+
+	t.Run("polling timeout", func(t *testing.T) {
+		fetch := func(ctx context.Context, txID string) (*types.TxReceipt, error) {
+			return nil, errors.New("not found")
+		}
+
+		f := newPollingTxFuture("tx1", fetch, &config.ReceiptPollingConfig{
+			InitialInterval: time.Millisecond,
+			Timeout:         50 * time.Millisecond,
+		}, nil)
+		receipt, err := f.Wait(context.Background())
+		require.Nil(t, receipt)
+		require.True(t, errors.Is(err, ErrReceiptTimeout))
+		require.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("wait context done", func(t *testing.T) {
+		fetch := func(ctx context.Context, txID string) (*types.TxReceipt, error) {
+			return nil, errors.New("not found")
+		}
+
+		f := newPollingTxFuture("tx1", fetch, pollingCfg, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		receipt, err := f.Wait(ctx)
+		require.Nil(t, receipt)
+		require.Equal(t, context.DeadlineExceeded, err)
+	})
+}
+
+func TestWhenDone(t *testing.T) {
+	var called bool
+	whenDone(newResolvedTxFuture("tx1", receiptWithFlag(types.Flag_VALID, "")), func(receipt *types.TxReceipt, err error) {
+		require.NoError(t, err)
+		require.NotNil(t, receipt)
+		called = true
+	})
+	require.True(t, called, "callback of resolved future must be called immediately")
+
+	release := make(chan struct{})
+	f := newPollingTxFuture("tx2", func(ctx context.Context, txID string) (*types.TxReceipt, error) {
+		<-release
+		return receiptWithFlag(types.Flag_INVALID_NO_PERMISSION, "no permission"), nil
+	}, &config.ReceiptPollingConfig{InitialInterval: time.Millisecond}, nil)
+	outcome := make(chan error, 1)
+	whenDone(f, func(receipt *types.TxReceipt, err error) {
+		require.NotNil(t, receipt)
+		outcome <- err
+	})
+	select {
+	case <-outcome:
+		require.Fail(t, "callback called before receipt is known")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	invalidErr := &TxInvalidError{}
+	require.True(t, errors.As(<-outcome, &invalidErr))
+}
@@ -0,0 +1,185 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// TxVerificationReport the result of Ledger.VerifyTx, each check of the transaction evidence reported separately
+type TxVerificationReport struct {
+	TxID        string
+	BlockNumber uint64
+	TxIndex     uint64
+	// TxIncluded transaction and its validation info are part of the tx merkle tree of the receipt block
+	TxIncluded bool
+	// HeaderInLedger receipt block header connected to the trusted block by verified ledger path
+	HeaderInLedger bool
+	// Unanchored neither trusted block header nor ledger checkpoint was available, ledger path was checked
+	// only against the genesis block returned by the same server, therefore HeaderInLedger is false
+	Unanchored bool
+	// TrustedBlockNumber the block ledger path verified from: trusted block header provided by the caller,
+	// ledger checkpoint if session verifies the ledger, genesis block returned by the server otherwise
+	TrustedBlockNumber uint64
+	// Valid transaction marked valid in the receipt block
+	Valid           bool
+	Flag            types.Flag
+	ReasonIfInvalid string
+	// Failures describes the failed checks, empty if transaction verified
+	Failures []string
+}
+
+// Verified returns true if all checks passed
+func (r *TxVerificationReport) Verified() bool {
+	return r.TxIncluded && r.HeaderInLedger && r.Valid
+}
+
+// txEvidenceSource provides transaction proofs, block headers and ledger paths, without verifying them
+type txEvidenceSource interface {
+	ledgerSource
+	getTransactionProof(ctx context.Context, blockNum uint64, txIndex int) (*TxProof, error)
+}
+
+// verifyTx verifies transaction envelope and its receipt end to end, failed checks are recorded in the report,
+// error returned only if verification can't be completed. Receipt block header is verified against trusted
+// header, if provided, or against the checkpoint of verifier
+func verifyTx(ctx context.Context, source txEvidenceSource, verifier *ledgerVerifier, trusted *types.BlockHeader, envelope proto.Message, receipt *types.TxReceipt) (*TxVerificationReport, error) {
+	header := receipt.GetHeader()
+	if header == nil {
+		return nil, errors.New("receipt has no block header")
+	}
+	txID, err := envelopeTxID(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TxVerificationReport{
+		TxID:        txID,
+		BlockNumber: header.GetBaseHeader().GetNumber(),
+		TxIndex:     receipt.GetTxIndex(),
+	}
+
+	proof, err := source.getTransactionProof(ctx, report.BlockNumber, int(report.TxIndex))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to fetch transaction proof")
+	}
+	if report.TxIncluded, err = proof.Verify(receipt, envelope); err != nil {
+		return nil, err
+	}
+	if !report.TxIncluded {
+		report.Failures = append(report.Failures, fmt.Sprintf("transaction is not part of tx merkle tree of block %d", report.BlockNumber))
+	}
+
+	var anchored bool
+	report.TrustedBlockNumber, anchored, err = verifyHeaderInLedger(ctx, source, verifier, trusted, header)
+	switch {
+	case err == nil:
+		report.HeaderInLedger = anchored
+	case errors.Is(err, ErrLedgerForked) || errors.Is(err, ErrLedgerPathBroken):
+		report.Failures = append(report.Failures, err.Error())
+	default:
+		return nil, err
+	}
+	if !anchored {
+		report.Unanchored = true
+		report.Failures = append(report.Failures, "ledger path isn't anchored to trusted block header or ledger checkpoint")
+	}
+
+	err = validateReceipt(txID, receipt)
+	invalidErr := &TxInvalidError{}
+	switch {
+	case err == nil:
+		report.Valid = true
+		report.Flag = types.Flag_VALID
+	case errors.As(err, &invalidErr):
+		report.Flag = invalidErr.Flag
+		report.ReasonIfInvalid = invalidErr.Reason
+		report.Failures = append(report.Failures, err.Error())
+	default:
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// verifyHeaderInLedger verifies that header is part of the ledger, starting from the trusted header or, if it is nil,
+// from the checkpoint of verifier. If neither is available, the header is checked against the genesis block returned
+// by the source, such a check isn't anchored. Returns the number of the block the header verified from and whenever
+// the check is anchored
+func verifyHeaderInLedger(ctx context.Context, source ledgerSource, verifier *ledgerVerifier, trusted, header *types.BlockHeader) (uint64, bool, error) {
+	if trusted != nil {
+		return trusted.GetBaseHeader().GetNumber(), true, verifyHeaderConnected(ctx, source, trusted, header)
+	}
+	if verifier != nil {
+		checkpoint, err := verifier.Checkpoint(ctx)
+		if err != nil {
+			return 0, true, err
+		}
+		return checkpoint.GetBaseHeader().GetNumber(), true, verifier.VerifyHeader(ctx, header)
+	}
+
+	genesis, err := source.getBlockHeader(ctx, 1)
+	if err != nil {
+		return 0, false, errors.WithMessage(err, "failed to fetch genesis block header")
+	}
+	return genesis.GetBaseHeader().GetNumber(), false, verifyHeaderConnected(ctx, source, genesis, header)
+}
+
+// verifyHeaderConnected verifies that header and trusted header are connected by ledger path, in either direction
+func verifyHeaderConnected(ctx context.Context, source ledgerSource, trusted, header *types.BlockHeader) error {
+	headerNum := header.GetBaseHeader().GetNumber()
+	trustedNum := trusted.GetBaseHeader().GetNumber()
+	if headerNum == trustedNum {
+		equal, err := headersEqual(header, trusted)
+		if err != nil {
+			return err
+		}
+		if !equal {
+			return errors.WithMessagef(ErrLedgerPathBroken, "block %d differs from trusted block", headerNum)
+		}
+		return nil
+	}
+
+	earlier, later := trusted, header
+	if headerNum < trustedNum {
+		earlier, later = header, trusted
+	}
+	path, err := source.getLedgerPath(ctx, earlier.GetBaseHeader().GetNumber(), later.GetBaseHeader().GetNumber())
+	if err != nil {
+		return errors.WithMessage(err, "failed to fetch ledger path")
+	}
+	if err = VerifyLedgerPath(path, earlier, later.GetBaseHeader().GetNumber()); err != nil {
+		return err
+	}
+	equal, err := headersEqual(path[0], later)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		return errors.WithMessagef(ErrLedgerPathBroken, "ledger path doesn't lead to block %d", later.GetBaseHeader().GetNumber())
+	}
+	return nil
+}
+
+// envelopeTxID returns the ID of the transaction in one of the envelopes returned by TxContext.TxEnvelope()
+func envelopeTxID(envelope proto.Message) (string, error) {
+	switch env := envelope.(type) {
+	case *types.DataTxEnvelope:
+		return env.GetPayload().GetTxID(), nil
+	case *types.UserAdministrationTxEnvelope:
+		return env.GetPayload().GetTxID(), nil
+	case *types.DBAdministrationTxEnvelope:
+		return env.GetPayload().GetTxID(), nil
+	case *types.ConfigTxEnvelope:
+		return env.GetPayload().GetTxID(), nil
+	case nil:
+		return "", errors.New("transaction envelope is nil")
+	default:
+		return "", errors.Errorf("tx [%s] has unsupported transaction envelope type %T", envelope.String(), envelope)
+	}
+}
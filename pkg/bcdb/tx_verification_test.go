@@ -0,0 +1,232 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/IBM-Blockchain/bcdb-server/pkg/crypto"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+type testTxEvidenceSource struct {
+	testLedgerSource
+	proof *TxProof
+}
+
+func (s *testTxEvidenceSource) getTransactionProof(_ context.Context, blockNum uint64, txIndex int) (*TxProof, error) {
+	if blockNum == 0 || blockNum > uint64(len(s.chain)) {
+		return nil, errors.New("block not found")
+	}
+	return s.proof, nil
+}
+
+// testAppendTxBlock appends to the chain block with the single transaction, marked by flag
+func testAppendTxBlock(t *testing.T, chain []*types.BlockHeader, tx proto.Message, flag types.Flag) ([]*types.BlockHeader, *TxProof) {
+	valInfo := &types.ValidationInfo{
+		Flag: flag,
+	}
+	if flag != types.Flag_VALID {
+		valInfo.ReasonIfInvalid = "reason"
+	}
+	txBytes, err := json.Marshal(tx)
+	require.NoError(t, err)
+	viBytes, err := json.Marshal(valInfo)
+	require.NoError(t, err)
+	txHash, err := crypto.ComputeSHA256Hash(append(txBytes, viBytes...))
+	require.NoError(t, err)
+
+	blockNum := uint64(len(chain) + 1)
+	header := &types.BlockHeader{
+		BaseHeader: &types.BlockHeaderBase{
+			Number: blockNum,
+		},
+		TxMerkelTreeRootHash: txHash,
+		ValidationInfo:       []*types.ValidationInfo{valInfo},
+	}
+	for _, link := range testSkipListLinks(blockNum) {
+		linkHash, err := ComputeBlockHeaderHash(chain[link-1])
+		require.NoError(t, err)
+		header.SkipchainHashes = append(header.SkipchainHashes, linkHash)
+	}
+	return append(chain, header), &TxProof{intermediateHashes: [][]byte{txHash}}
+}
+
+func TestVerifyTx(t *testing.T) {
+	ctx := context.Background()
+	tx := &types.DataTxEnvelope{
+		Payload: &types.DataTx{
+			MustSignUserIDs: []string{"alice"},
+			TxID:            "tx1",
+		},
+		Signatures: map[string][]byte{"alice": {1}},
+	}
+
+	chain := testLedgerChain(t, 5)
+	chain, proof := testAppendTxBlock(t, chain, tx, types.Flag_VALID)
+	chain = testExtendLedgerChain(t, chain, 12, 0)
+	receipt := &types.TxReceipt{
+		Header:  chain[5],
+		TxIndex: 0,
+	}
+
+	t.Run("verified from trusted header", func(t *testing.T) {
+		source := &testTxEvidenceSource{testLedgerSource: testLedgerSource{chain: chain}, proof: proof}
+		report, err := verifyTx(ctx, source, nil, chain[0], tx, receipt)
+		require.NoError(t, err)
+		require.True(t, report.Verified())
+		require.Equal(t, &TxVerificationReport{
+			TxID:               "tx1",
+			BlockNumber:        6,
+			TxIndex:            0,
+			TxIncluded:         true,
+			HeaderInLedger:     true,
+			TrustedBlockNumber: 1,
+			Valid:              true,
+			Flag:               types.Flag_VALID,
+		}, report)
+
+		// trusted header later than receipt block
+		report, err = verifyTx(ctx, source, nil, chain[11], tx, receipt)
+		require.NoError(t, err)
+		require.True(t, report.Verified())
+		require.Equal(t, uint64(12), report.TrustedBlockNumber)
+
+		report, err = verifyTx(ctx, source, nil, chain[5], tx, receipt)
+		require.NoError(t, err)
+		require.True(t, report.Verified())
+	})
+
+	t.Run("unanchored without trusted header", func(t *testing.T) {
+		source := &testTxEvidenceSource{testLedgerSource: testLedgerSource{chain: chain}, proof: proof}
+		report, err := verifyTx(ctx, source, nil, nil, tx, receipt)
+		require.NoError(t, err)
+		require.False(t, report.Verified())
+		require.Equal(t, &TxVerificationReport{
+			TxID:               "tx1",
+			BlockNumber:        6,
+			TxIndex:            0,
+			TxIncluded:         true,
+			HeaderInLedger:     false,
+			Unanchored:         true,
+			TrustedBlockNumber: 1,
+			Valid:              true,
+			Flag:               types.Flag_VALID,
+			Failures:           []string{"ledger path isn't anchored to trusted block header or ledger checkpoint"},
+		}, report)
+	})
+
+	t.Run("trusted header of other ledger", func(t *testing.T) {
+		source := &testTxEvidenceSource{testLedgerSource: testLedgerSource{chain: chain}, proof: proof}
+		otherChain := testExtendLedgerChain(t, chain[:3], 10, 1)
+		report, err := verifyTx(ctx, source, nil, otherChain[9], tx, receipt)
+		require.NoError(t, err)
+		require.False(t, report.Verified())
+		require.False(t, report.HeaderInLedger)
+		require.False(t, report.Unanchored)
+		require.Len(t, report.Failures, 1)
+		require.True(t, report.TxIncluded)
+	})
+
+	t.Run("verified from checkpoint", func(t *testing.T) {
+		source := &testTxEvidenceSource{testLedgerSource: testLedgerSource{chain: chain}, proof: proof}
+		store := &memoryCheckpointStore{header: chain[9]}
+		report, err := verifyTx(ctx, source, newTestLedgerVerifier(&source.testLedgerSource, store), nil, tx, receipt)
+		require.NoError(t, err)
+		require.True(t, report.Verified())
+		require.Equal(t, uint64(10), report.TrustedBlockNumber)
+		require.Equal(t, chain[9], store.header)
+	})
+
+	t.Run("tampered transaction", func(t *testing.T) {
+		source := &testTxEvidenceSource{testLedgerSource: testLedgerSource{chain: chain}, proof: proof}
+		tampered := &types.DataTxEnvelope{
+			Payload: &types.DataTx{
+				MustSignUserIDs: []string{"alice"},
+				TxID:            "tx1",
+				DBOperations: []*types.DBOperation{
+					{DBName: "bdb"},
+				},
+			},
+			Signatures: tx.Signatures,
+		}
+		report, err := verifyTx(ctx, source, nil, chain[0], tampered, receipt)
+		require.NoError(t, err)
+		require.False(t, report.Verified())
+		require.False(t, report.TxIncluded)
+		require.True(t, report.HeaderInLedger)
+		require.True(t, report.Valid)
+		require.Equal(t, []string{"transaction is not part of tx merkle tree of block 6"}, report.Failures)
+	})
+
+	t.Run("header not in ledger", func(t *testing.T) {
+		forked := testExtendLedgerChain(t, chain[:3], 5, 1)
+		forked, forkedProof := testAppendTxBlock(t, forked, tx, types.Flag_VALID)
+		source := &testTxEvidenceSource{testLedgerSource: testLedgerSource{chain: chain}, proof: forkedProof}
+		report, err := verifyTx(ctx, source, nil, chain[0], tx, &types.TxReceipt{
+			Header:  forked[5],
+			TxIndex: 0,
+		})
+		require.NoError(t, err)
+		require.False(t, report.Verified())
+		require.True(t, report.TxIncluded)
+		require.False(t, report.HeaderInLedger)
+		require.True(t, report.Valid)
+		require.Len(t, report.Failures, 1)
+		require.Contains(t, report.Failures[0], "ledger path doesn't lead to block 6")
+	})
+
+	t.Run("header not in ledger with checkpoint", func(t *testing.T) {
+		forked := testExtendLedgerChain(t, chain[:3], 5, 1)
+		forked, forkedProof := testAppendTxBlock(t, forked, tx, types.Flag_VALID)
+		source := &testTxEvidenceSource{testLedgerSource: testLedgerSource{chain: chain}, proof: forkedProof}
+		store := &memoryCheckpointStore{header: chain[9]}
+		report, err := verifyTx(ctx, source, newTestLedgerVerifier(&source.testLedgerSource, store), nil, tx, &types.TxReceipt{
+			Header:  forked[5],
+			TxIndex: 0,
+		})
+		require.NoError(t, err)
+		require.False(t, report.HeaderInLedger)
+		require.Equal(t, uint64(10), report.TrustedBlockNumber)
+		require.Len(t, report.Failures, 1)
+		require.Contains(t, report.Failures[0], ErrLedgerForked.Error())
+	})
+
+	t.Run("invalid transaction", func(t *testing.T) {
+		invalidChain, invalidProof := testAppendTxBlock(t, chain[:5], tx, types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE)
+		source := &testTxEvidenceSource{testLedgerSource: testLedgerSource{chain: invalidChain}, proof: invalidProof}
+		report, err := verifyTx(ctx, source, nil, invalidChain[0], tx, &types.TxReceipt{
+			Header:  invalidChain[5],
+			TxIndex: 0,
+		})
+		require.NoError(t, err)
+		require.False(t, report.Verified())
+		require.True(t, report.TxIncluded)
+		require.True(t, report.HeaderInLedger)
+		require.False(t, report.Valid)
+		require.Equal(t, types.Flag_INVALID_MVCC_CONFLICT_WITH_COMMITTED_STATE, report.Flag)
+		require.Equal(t, "reason", report.ReasonIfInvalid)
+		require.Len(t, report.Failures, 1)
+	})
+
+	t.Run("verification not completed", func(t *testing.T) {
+		source := &testTxEvidenceSource{testLedgerSource: testLedgerSource{chain: chain[:3]}, proof: proof}
+		report, err := verifyTx(ctx, source, nil, nil, tx, receipt)
+		require.EqualError(t, err, "failed to fetch transaction proof: block not found")
+		require.Nil(t, report)
+
+		report, err = verifyTx(ctx, source, nil, nil, tx, &types.TxReceipt{})
+		require.EqualError(t, err, "receipt has no block header")
+		require.Nil(t, report)
+
+		report, err = verifyTx(ctx, source, nil, nil, &types.DataTx{TxID: "tx1"}, receipt)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "has unsupported transaction envelope type *types.DataTx")
+		require.Nil(t, report)
+	})
+}
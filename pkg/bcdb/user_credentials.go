@@ -0,0 +1,176 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package bcdb
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/internal/pkcs8"
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/crypto"
+	"github.com/pkg/errors"
+)
+
+// newSigner creates signer from PEM encoded private key, plain or encrypted, either encrypted PKCS#8
+// or legacy encrypted PEM, passphrase is requested from the provider only if the key is encrypted
+func newSigner(userID string, keyPEM []byte, passphraseProvider config.PassphraseProvider) (Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, malformedPrivateKeyError(errors.New("no PEM encoded private key found"))
+	}
+
+	var key interface{}
+	switch {
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		passphrase, err := readPassphrase(passphraseProvider)
+		if err != nil {
+			return nil, err
+		}
+		der, err := pkcs8.Decrypt(block.Bytes, passphrase)
+		if err == pkcs8.ErrIncorrectPassphrase {
+			return nil, errors.Wrap(ErrIncorrectPassphrase, "failed to decrypt PKCS#8 private key")
+		}
+		if err != nil {
+			return nil, malformedPrivateKeyError(err)
+		}
+		// garbage decrypted with wrong passphrase may occasionally have valid padding
+		if key, err = x509.ParsePKCS8PrivateKey(der); err != nil {
+			return nil, errors.Wrap(ErrIncorrectPassphrase, "failed to decrypt PKCS#8 private key")
+		}
+
+	case x509.IsEncryptedPEMBlock(block):
+		passphrase, err := readPassphrase(passphraseProvider)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.DecryptPEMBlock(block, passphrase)
+		if err == x509.IncorrectPasswordError {
+			return nil, errors.Wrap(ErrIncorrectPassphrase, "failed to decrypt PEM private key")
+		}
+		if err != nil {
+			return nil, malformedPrivateKeyError(err)
+		}
+		if key, err = parsePrivateKey(der); err != nil {
+			return nil, errors.Wrap(ErrIncorrectPassphrase, "failed to decrypt PEM private key")
+		}
+
+	default:
+		var err error
+		if key, err = parsePrivateKey(block.Bytes); err != nil {
+			return nil, malformedPrivateKeyError(err)
+		}
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("unsupported private key type %T, ECDSA key expected", key)
+	}
+	return &ecdsaSigner{
+		identity: userID,
+		key:      ecdsaKey,
+	}, nil
+}
+
+// parsePrivateKey parses either PKCS#8 or SEC 1 DER encoded private key
+func parsePrivateKey(der []byte) (interface{}, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParseECPrivateKey(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse private key")
+	}
+	return key, nil
+}
+
+func readPassphrase(passphraseProvider config.PassphraseProvider) ([]byte, error) {
+	if passphraseProvider == nil {
+		return nil, errors.New("private key is encrypted, but passphrase provider is not set")
+	}
+	passphrase, err := passphraseProvider()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get private key passphrase")
+	}
+	return passphrase, nil
+}
+
+func malformedPrivateKeyError(err error) error {
+	return fmt.Errorf("%w: %s", ErrMalformedPrivateKey, err)
+}
+
+// ecdsaSigner signs with the key given in memory or decrypted, as bcdb-server crypto package builds
+// signers from plain key files only. Signature is the same as of crypto.NewSigner, i.e. ASN.1 encoded
+// ECDSA signature over the hash computed by crypto.ComputeSHA256Hash
+type ecdsaSigner struct {
+	identity string
+	key      *ecdsa.PrivateKey
+}
+
+func (s *ecdsaSigner) Sign(msgBytes []byte) ([]byte, error) {
+	digest, err := crypto.ComputeSHA256Hash(msgBytes)
+	if err != nil {
+		return nil, err
+	}
+	return ecdsa.SignASN1(rand.Reader, s.key, digest)
+}
+
+func (s *ecdsaSigner) Identity() string {
+	return s.identity
+}
+
+// userSigner returns user's signer, provided one or built from the private key, given in memory or as file
+func userSigner(c *config.UserConfig) (Signer, error) {
+	switch {
+	case c.Signer != nil:
+		return c.Signer, nil
+	case len(c.PrivateKey) > 0:
+		return newSigner(c.UserID, c.PrivateKey, c.PassphraseProvider)
+	case c.PrivateKeyPath != "":
+		keyPEM, err := ioutil.ReadFile(c.PrivateKeyPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read private key file %s", c.PrivateKeyPath)
+		}
+		// Plain PKCS#8 key file is loaded by bcdb-server crypto package, other encodings are parsed by the SDK
+		if block, _ := pem.Decode(keyPEM); block != nil && block.Type == "PRIVATE KEY" {
+			return crypto.NewSigner(&crypto.SignerOptions{
+				Identity:    c.UserID,
+				KeyFilePath: c.PrivateKeyPath,
+			})
+		}
+		return newSigner(c.UserID, keyPEM, c.PassphraseProvider)
+	default:
+		return nil, errors.New("neither signer, private key nor private key path is provided")
+	}
+}
+
+// userCertificate returns PEM encoded user's certificate, the same bytes regardless of the way
+// certificate was provided, as certificate is used to compute transaction IDs
+func userCertificate(c *config.UserConfig) ([]byte, error) {
+	switch {
+	case c.Certificate != nil:
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Certificate.Raw}), nil
+	case len(c.Cert) > 0:
+		if block, _ := pem.Decode(c.Cert); block != nil {
+			if block.Type != "CERTIFICATE" {
+				return nil, errors.Errorf("unexpected PEM block type %s of user's certificate", block.Type)
+			}
+			if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+				return nil, errors.Wrap(err, "failed to parse user's certificate")
+			}
+			return c.Cert, nil
+		}
+		if _, err := x509.ParseCertificate(c.Cert); err != nil {
+			return nil, errors.Wrap(err, "failed to parse user's certificate, neither PEM nor DER encoded")
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert}), nil
+	case c.CertPath != "":
+		return ioutil.ReadFile(c.CertPath)
+	default:
+		return nil, errors.New("neither certificate nor certificate path is provided")
+	}
+}
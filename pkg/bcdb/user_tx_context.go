@@ -3,6 +3,8 @@
 package bcdb
 
 import (
+	"context"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/constants"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/cryptoservice"
@@ -22,6 +24,8 @@ type UsersTxContext interface {
 	PutUser(user *types.User, acl *types.AccessControl) error
 	// GetUser obtain user's record from database
 	GetUser(userID string) (*types.User, error)
+	// GetUserCtx same as GetUser, ctx controls the request sent to the server
+	GetUserCtx(ctx context.Context, userID string) (*types.User, error)
 	// RemoveUser delete existing user from the database
 	RemoveUser(userID string) error
 }
@@ -34,14 +38,34 @@ type userTxContext struct {
 }
 
 func (u *userTxContext) Commit(sync bool) (string, *types.TxReceipt, error) {
-	return u.commit(u, constants.PostUserTx, sync)
+	return u.CommitCtx(context.Background(), sync)
+}
+
+func (u *userTxContext) CommitCtx(ctx context.Context, sync bool) (string, *types.TxReceipt, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.commit(ctx, u, constants.PostUserTx, sync)
+}
+
+func (u *userTxContext) CommitFuture(ctx context.Context, sync bool) (TxFuture, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.commitFuture(ctx, u, constants.PostUserTx, sync)
 }
 
 func (u *userTxContext) Abort() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	return u.abort(u)
 }
 
 func (u *userTxContext) PutUser(user *types.User, acl *types.AccessControl) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	if u.txSpent {
 		return ErrTxSpent
 	}
@@ -55,13 +79,20 @@ func (u *userTxContext) PutUser(user *types.User, acl *types.AccessControl) erro
 }
 
 func (u *userTxContext) GetUser(userID string) (*types.User, error) {
+	return u.GetUserCtx(context.Background(), userID)
+}
+
+func (u *userTxContext) GetUserCtx(ctx context.Context, userID string) (*types.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	if u.txSpent {
 		return nil, ErrTxSpent
 	}
 
 	path := constants.URLForGetUser(userID)
 	res := &types.GetUserResponse{}
-	err := u.handleRequest(path, &types.GetUserQuery{
+	err := u.handleRequest(ctx, path, &types.GetUserQuery{
 		UserID:       u.userID,
 		TargetUserID: userID,
 	}, res)
@@ -78,6 +109,9 @@ func (u *userTxContext) GetUser(userID string) (*types.User, error) {
 }
 
 func (u *userTxContext) RemoveUser(userID string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	if u.txSpent {
 		return ErrTxSpent
 	}
@@ -207,8 +207,8 @@ func TestUserContext_TxSubmissionFullScenario(t *testing.T) {
 				},
 			}),
 		}),
-		Signature: []byte{0},
 	}
+	queryResult.Signature = testNodeIdentity.sign(queryResult.Payload)
 	queryResultBytes, err := json.Marshal(queryResult)
 	require.NoError(t, err)
 	require.NotNil(t, queryResultBytes)
@@ -235,6 +235,7 @@ func TestUserContext_TxSubmissionFullScenario(t *testing.T) {
 			signer:     signer,
 			userID:     "testUserId",
 			restClient: restClient,
+			nodesCerts: testNodesCerts(),
 			logger:     logger,
 			replicaSet: map[string]*url.URL{
 				"node1": {
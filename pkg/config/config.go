@@ -3,9 +3,12 @@
 package config
 
 import (
+	"crypto/x509"
 	"time"
 
+	"github.com/IBM-Blockchain/bcdb-server/pkg/crypto"
 	"github.com/IBM-Blockchain/bcdb-server/pkg/logger"
+	"github.com/IBM-Blockchain/bcdb-server/pkg/types"
 )
 
 // Replica
@@ -22,10 +25,69 @@ type Replica struct {
 type ConnectionConfig struct {
 	// List of replicas URIs client can connect to
 	ReplicaSet []*Replica
-	// Keeps path to the server's root CA
+	// Keeps paths to the PEM files with the server's CA certificates, each file may contain
+	// a bundle of root and intermediate CA certificates. Each certificate is trusted, e.g. pinned
+	// intermediate CA without its root is enough to verify the certificates it issued
 	RootCAs []string
 	// Logger instance, if nil an internal logger is created
 	Logger *logger.SugarLogger
+	// ReplicaSelection defines how replicas are picked to serve requests,
+	// if nil sticky strategy with default health check interval is used
+	ReplicaSelection *ReplicaSelectionConfig
+	// TLS configures HTTPS connection to the replicas, if nil default TLS settings are used for
+	// `https` endpoints and the server's TLS certificate is verified with RootCAs
+	TLS *TLSConfig
+	// Discovery if set, ReplicaSet is used only to bootstrap the connection and the replicas
+	// are discovered from the nodes of the cluster configuration
+	Discovery *DiscoveryConfig
+}
+
+// DiscoveryConfig configures discovery of the replicas from the cluster configuration.
+// Replica endpoint is built from the node's address and port, with the scheme of the
+// bootstrap endpoints. Bootstrap endpoint of the node with the same ID takes precedence,
+// e.g. if the node is reachable by the client with an address other than its configured one
+type DiscoveryConfig struct {
+	// RefreshInterval interval between refreshes of the cluster membership, 1 minute if 0.
+	// The membership is also refreshed when a config transaction changing the cluster nodes
+	// is committed synchronously by one of the sessions
+	RefreshInterval time.Duration
+}
+
+// TLSConfig TLS settings of the connection to the replicas
+type TLSConfig struct {
+	// CACertsPaths paths to PEM encoded CA certificates used to verify the server's TLS
+	// certificate, RootCAs are used if empty
+	CACertsPaths []string
+	// ClientCertPath path to PEM encoded client certificate, presented to the server for mutual TLS
+	ClientCertPath string
+	// ClientKeyPath path to PEM encoded private key of the client certificate
+	ClientKeyPath string
+	// ServerNameOverride the name used to verify the server's certificate instead of the endpoint host name
+	ServerNameOverride string
+	// MinVersion minimal accepted TLS version, e.g. tls.VersionTLS13, TLS 1.2 if 0
+	MinVersion uint16
+}
+
+// ReplicaSelectionStrategy the way SDK picks replica to send request to
+type ReplicaSelectionStrategy string
+
+const (
+	// ReplicaSelectionSticky keeps sending requests to same replica, until it fails
+	ReplicaSelectionSticky ReplicaSelectionStrategy = "sticky"
+	// ReplicaSelectionRoundRobin spreads requests evenly between all healthy replicas
+	ReplicaSelectionRoundRobin ReplicaSelectionStrategy = "round-robin"
+	// ReplicaSelectionLeastLatency sends requests to the healthy replica with the lowest observed latency
+	ReplicaSelectionLeastLatency ReplicaSelectionStrategy = "least-latency"
+)
+
+// ReplicaSelectionConfig replica selection and health tracking configuration
+type ReplicaSelectionConfig struct {
+	// Strategy used to pick replica, sticky if empty
+	Strategy ReplicaSelectionStrategy
+	// HealthCheckInterval interval between probes of unhealthy replica, 5 seconds if 0
+	HealthCheckInterval time.Duration
+	// HealthCheckTimeout timeout of single probe of unhealthy replica, 1 second if 0
+	HealthCheckTimeout time.Duration
 }
 
 // SessionConfig keeps per database session
@@ -38,10 +100,53 @@ type SessionConfig struct {
 	TxTimeout time.Duration
 	// The query timeout - SDK will wait for query result maximum `QueryTimeout` time.
 	QueryTimeout time.Duration
+	// ReceiptPolling defines how SDK polls transaction receipt for `tx.CommitFuture()`,
+	// if nil default polling intervals are used
+	ReceiptPolling *ReceiptPollingConfig
+	// StrictSnapshotReads if true, `DataTxContext.Get` ignores pending writes and deletes of
+	// the same transaction and always returns the value read from the server.
+	// By default `Get` returns pending value of the key, or nil if key deletion is pending
+	StrictSnapshotReads bool
+	// LedgerVerification if set, every block header observed by the session, i.e. returned by
+	// ledger queries or included in transaction receipt, is verified to extend trusted checkpoint
+	LedgerVerification *LedgerVerificationConfig
+	// NodeCertsCacheTTL how long the session reuses cluster configuration and node certificates
+	// downloaded from the server before refreshing them, 5 minutes if 0. The cache is refreshed
+	// earlier when config transaction is committed or response signature can't be verified
+	NodeCertsCacheTTL time.Duration
+}
+
+// LedgerVerificationConfig configures storage of trusted ledger checkpoint
+type LedgerVerificationConfig struct {
+	// CheckpointPath path to the file trusted checkpoint persisted to, used if CheckpointStore is nil
+	CheckpointPath string
+	// CheckpointStore custom storage of trusted checkpoint
+	CheckpointStore CheckpointStore
+}
+
+// CheckpointStore persists trusted block header checkpoint. If store is empty, SDK trusts
+// the genesis block returned by the server, pin a header by storing it before the first use
+type CheckpointStore interface {
+	// Load returns stored checkpoint, nil if checkpoint wasn't stored yet
+	Load() (*types.BlockHeader, error)
+	// Store persists new checkpoint
+	Store(header *types.BlockHeader) error
+}
+
+// ReceiptPollingConfig controls polling of transaction receipt of asynchronously committed transactions
+type ReceiptPollingConfig struct {
+	// InitialInterval interval before first receipt poll, 100 milliseconds if 0
+	InitialInterval time.Duration
+	// MaxInterval polling interval doubles after each attempt up to MaxInterval, 2 seconds if 0
+	MaxInterval time.Duration
+	// Timeout SDK gives up waiting for transaction receipt after Timeout, 1 minute if 0
+	Timeout time.Duration
 }
 
 // UserConfig user related information
-// maintains wallet with public and private keys
+// maintains wallet with public and private keys.
+// Credentials can be given either as file paths or in memory,
+// in-memory values take precedence over the paths
 type UserConfig struct {
 	// UserID the identity of the user
 	UserID string
@@ -49,4 +154,16 @@ type UserConfig struct {
 	CertPath string
 	// PrivateKeyPath path to the user's private key
 	PrivateKeyPath string
+	// PassphraseProvider provides the passphrase of encrypted private key, either encrypted
+	// PKCS#8 or legacy encrypted PEM, given as PrivateKeyPath or PrivateKey
+	PassphraseProvider PassphraseProvider
+	// Certificate the user's certificate, used instead of Cert and CertPath
+	Certificate *x509.Certificate
+	// Cert PEM or DER encoded user's certificate, used instead of CertPath
+	Cert []byte
+	// PrivateKey PEM encoded user's private key, used instead of PrivateKeyPath
+	PrivateKey []byte
+	// Signer signs transactions and queries on behalf of the user, e.g. key kept by
+	// a remote signing service, used instead of PrivateKey and PrivateKeyPath
+	Signer crypto.Signer
 }
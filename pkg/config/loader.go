@@ -0,0 +1,438 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DefaultTxTimeout transaction timeout used by LoadSessionConfig if not set in the file
+	DefaultTxTimeout = 5 * time.Second
+	// DefaultQueryTimeout query timeout used by LoadSessionConfig if not set in the file
+	DefaultQueryTimeout = 10 * time.Second
+)
+
+// FieldError returned by LoadConnectionConfig and LoadSessionConfig when configuration field
+// is missing or has invalid value
+type FieldError struct {
+	// Field the path of the field in the configuration file, e.g. replicaSet[1].endpoint
+	Field string
+	// Reason the description of the problem
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid configuration field %s: %s", e.Field, e.Reason)
+}
+
+type replicaFile struct {
+	ID       string `yaml:"id" json:"id"`
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+}
+
+type replicaSelectionFile struct {
+	Strategy            string `yaml:"strategy" json:"strategy"`
+	HealthCheckInterval string `yaml:"healthCheckInterval" json:"healthCheckInterval"`
+	HealthCheckTimeout  string `yaml:"healthCheckTimeout" json:"healthCheckTimeout"`
+}
+
+type tlsFile struct {
+	CACertsPaths       []string `yaml:"caCertsPaths" json:"caCertsPaths"`
+	ClientCertPath     string   `yaml:"clientCertPath" json:"clientCertPath"`
+	ClientKeyPath      string   `yaml:"clientKeyPath" json:"clientKeyPath"`
+	ServerNameOverride string   `yaml:"serverNameOverride" json:"serverNameOverride"`
+	MinVersion         string   `yaml:"minVersion" json:"minVersion"`
+}
+
+type discoveryFile struct {
+	RefreshInterval string `yaml:"refreshInterval" json:"refreshInterval"`
+}
+
+type connectionConfigFile struct {
+	ReplicaSet       []*replicaFile        `yaml:"replicaSet" json:"replicaSet"`
+	RootCAs          []string              `yaml:"rootCAs" json:"rootCAs"`
+	ReplicaSelection *replicaSelectionFile `yaml:"replicaSelection" json:"replicaSelection"`
+	TLS              *tlsFile              `yaml:"tls" json:"tls"`
+	Discovery        *discoveryFile        `yaml:"discovery" json:"discovery"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+type userConfigFile struct {
+	UserID         string `yaml:"userID" json:"userID"`
+	CertPath       string `yaml:"certPath" json:"certPath"`
+	PrivateKeyPath string `yaml:"privateKeyPath" json:"privateKeyPath"`
+	PassphraseEnv  string `yaml:"passphraseEnv" json:"passphraseEnv"`
+	PassphraseFile string `yaml:"passphraseFile" json:"passphraseFile"`
+}
+
+type receiptPollingFile struct {
+	InitialInterval string `yaml:"initialInterval" json:"initialInterval"`
+	MaxInterval     string `yaml:"maxInterval" json:"maxInterval"`
+	Timeout         string `yaml:"timeout" json:"timeout"`
+}
+
+type ledgerVerificationFile struct {
+	CheckpointPath string `yaml:"checkpointPath" json:"checkpointPath"`
+}
+
+type sessionConfigFile struct {
+	UserConfig          *userConfigFile         `yaml:"userConfig" json:"userConfig"`
+	TxTimeout           string                  `yaml:"txTimeout" json:"txTimeout"`
+	QueryTimeout        string                  `yaml:"queryTimeout" json:"queryTimeout"`
+	ReceiptPolling      *receiptPollingFile     `yaml:"receiptPolling" json:"receiptPolling"`
+	StrictSnapshotReads bool                    `yaml:"strictSnapshotReads" json:"strictSnapshotReads"`
+	LedgerVerification  *ledgerVerificationFile `yaml:"ledgerVerification" json:"ledgerVerification"`
+	NodeCertsCacheTTL   string                  `yaml:"nodeCertsCacheTTL" json:"nodeCertsCacheTTL"`
+}
+
+// LoadConnectionConfig reads connection configuration from YAML (.yaml, .yml) or JSON (.json) file.
+// References to environment variables, `${VAR}` or `${VAR:-default}`, are substituted in string values
+// after the file is parsed, *FieldError returned if they are used in other values, e.g. booleans. Relative paths are resolved against the directory of the file. Durations are written
+// as strings, e.g. "5s". Logger isn't part of the file and should be set by the caller if needed
+func LoadConnectionConfig(path string) (*ConnectionConfig, error) {
+	file := &connectionConfigFile{}
+	if err := loadConfigFile(path, file); err != nil {
+		return nil, err
+	}
+
+	if len(file.ReplicaSet) == 0 {
+		return nil, &FieldError{Field: "replicaSet", Reason: "at least one replica is required"}
+	}
+	c := &ConnectionConfig{}
+	replicaIDs := map[string]bool{}
+	for i, r := range file.ReplicaSet {
+		field := fmt.Sprintf("replicaSet[%d]", i)
+		if r == nil {
+			return nil, &FieldError{Field: field, Reason: "replica is empty"}
+		}
+		if r.ID == "" {
+			return nil, &FieldError{Field: field + ".id", Reason: "replica ID is empty"}
+		}
+		if replicaIDs[r.ID] {
+			return nil, &FieldError{Field: field + ".id", Reason: fmt.Sprintf("duplicate replica ID %s", r.ID)}
+		}
+		replicaIDs[r.ID] = true
+		endpoint, err := url.Parse(r.Endpoint)
+		if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+			return nil, &FieldError{Field: field + ".endpoint", Reason: fmt.Sprintf("invalid replica endpoint %q", r.Endpoint)}
+		}
+		c.ReplicaSet = append(c.ReplicaSet, &Replica{ID: r.ID, Endpoint: r.Endpoint})
+	}
+
+	for i, rootCA := range file.RootCAs {
+		if rootCA == "" {
+			return nil, &FieldError{Field: fmt.Sprintf("rootCAs[%d]", i), Reason: "path is empty"}
+		}
+		c.RootCAs = append(c.RootCAs, resolvePath(path, rootCA))
+	}
+
+	if s := file.ReplicaSelection; s != nil {
+		c.ReplicaSelection = &ReplicaSelectionConfig{
+			Strategy: ReplicaSelectionStrategy(s.Strategy),
+		}
+		switch c.ReplicaSelection.Strategy {
+		case "", ReplicaSelectionSticky, ReplicaSelectionRoundRobin, ReplicaSelectionLeastLatency:
+		default:
+			return nil, &FieldError{Field: "replicaSelection.strategy", Reason: fmt.Sprintf("unknown strategy %q", s.Strategy)}
+		}
+		var err error
+		if c.ReplicaSelection.HealthCheckInterval, err = parseDuration("replicaSelection.healthCheckInterval", s.HealthCheckInterval, 0); err != nil {
+			return nil, err
+		}
+		if c.ReplicaSelection.HealthCheckTimeout, err = parseDuration("replicaSelection.healthCheckTimeout", s.HealthCheckTimeout, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if t := file.TLS; t != nil {
+		c.TLS = &TLSConfig{
+			ServerNameOverride: t.ServerNameOverride,
+		}
+		for i, caPath := range t.CACertsPaths {
+			if caPath == "" {
+				return nil, &FieldError{Field: fmt.Sprintf("tls.caCertsPaths[%d]", i), Reason: "path is empty"}
+			}
+			c.TLS.CACertsPaths = append(c.TLS.CACertsPaths, resolvePath(path, caPath))
+		}
+		if (t.ClientCertPath == "") != (t.ClientKeyPath == "") {
+			field := "tls.clientKeyPath"
+			if t.ClientCertPath == "" {
+				field = "tls.clientCertPath"
+			}
+			return nil, &FieldError{Field: field, Reason: "client certificate and key should be set together"}
+		}
+		if t.ClientCertPath != "" {
+			c.TLS.ClientCertPath = resolvePath(path, t.ClientCertPath)
+			c.TLS.ClientKeyPath = resolvePath(path, t.ClientKeyPath)
+		}
+		if t.MinVersion != "" {
+			version, ok := tlsVersions[t.MinVersion]
+			if !ok {
+				return nil, &FieldError{Field: "tls.minVersion", Reason: fmt.Sprintf("unknown TLS version %q, expected one of 1.0, 1.1, 1.2, 1.3", t.MinVersion)}
+			}
+			c.TLS.MinVersion = version
+		}
+	}
+
+	if d := file.Discovery; d != nil {
+		c.Discovery = &DiscoveryConfig{}
+		var err error
+		if c.Discovery.RefreshInterval, err = parseDuration("discovery.refreshInterval", d.RefreshInterval, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// LoadSessionConfig reads session configuration from YAML (.yaml, .yml) or JSON (.json) file, the file
+// format is the same as of LoadConnectionConfig. TxTimeout and QueryTimeout default to DefaultTxTimeout
+// and DefaultQueryTimeout. Custom CheckpointStore isn't part of the file and should be set by the caller if needed
+func LoadSessionConfig(path string) (*SessionConfig, error) {
+	file := &sessionConfigFile{}
+	if err := loadConfigFile(path, file); err != nil {
+		return nil, err
+	}
+
+	u := file.UserConfig
+	if u == nil {
+		return nil, &FieldError{Field: "userConfig", Reason: "user configuration is required"}
+	}
+	if u.UserID == "" {
+		return nil, &FieldError{Field: "userConfig.userID", Reason: "user ID is empty"}
+	}
+	if u.CertPath == "" {
+		return nil, &FieldError{Field: "userConfig.certPath", Reason: "path is empty"}
+	}
+	if u.PrivateKeyPath == "" {
+		return nil, &FieldError{Field: "userConfig.privateKeyPath", Reason: "path is empty"}
+	}
+	if u.PassphraseEnv != "" && u.PassphraseFile != "" {
+		return nil, &FieldError{Field: "userConfig.passphraseFile", Reason: "passphrase environment variable and file are mutually exclusive"}
+	}
+
+	c := &SessionConfig{
+		UserConfig: &UserConfig{
+			UserID:         u.UserID,
+			CertPath:       resolvePath(path, u.CertPath),
+			PrivateKeyPath: resolvePath(path, u.PrivateKeyPath),
+		},
+		StrictSnapshotReads: file.StrictSnapshotReads,
+	}
+	switch {
+	case u.PassphraseEnv != "":
+		c.UserConfig.PassphraseProvider = EnvPassphraseProvider(u.PassphraseEnv)
+	case u.PassphraseFile != "":
+		c.UserConfig.PassphraseProvider = FilePassphraseProvider(resolvePath(path, u.PassphraseFile))
+	}
+	var err error
+	if c.TxTimeout, err = parseDuration("txTimeout", file.TxTimeout, DefaultTxTimeout); err != nil {
+		return nil, err
+	}
+	if c.QueryTimeout, err = parseDuration("queryTimeout", file.QueryTimeout, DefaultQueryTimeout); err != nil {
+		return nil, err
+	}
+	if c.NodeCertsCacheTTL, err = parseDuration("nodeCertsCacheTTL", file.NodeCertsCacheTTL, 0); err != nil {
+		return nil, err
+	}
+
+	if p := file.ReceiptPolling; p != nil {
+		c.ReceiptPolling = &ReceiptPollingConfig{}
+		if c.ReceiptPolling.InitialInterval, err = parseDuration("receiptPolling.initialInterval", p.InitialInterval, 0); err != nil {
+			return nil, err
+		}
+		if c.ReceiptPolling.MaxInterval, err = parseDuration("receiptPolling.maxInterval", p.MaxInterval, 0); err != nil {
+			return nil, err
+		}
+		if c.ReceiptPolling.Timeout, err = parseDuration("receiptPolling.timeout", p.Timeout, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := file.LedgerVerification; v != nil {
+		if v.CheckpointPath == "" {
+			return nil, &FieldError{Field: "ledgerVerification.checkpointPath", Reason: "path is empty"}
+		}
+		c.LedgerVerification = &LedgerVerificationConfig{
+			CheckpointPath: resolvePath(path, v.CheckpointPath),
+		}
+	}
+
+	return c, nil
+}
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// loadConfigFile reads the file, parses it according to file extension and substitutes environment variables
+// in string fields of out
+func loadConfigFile(path string, out interface{}) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read configuration file %s", path)
+	}
+
+	var raw interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err = yaml.Unmarshal(content, &raw); err == nil {
+			if err = checkEnvReferences("", raw, reflect.TypeOf(out), "yaml"); err != nil {
+				return err
+			}
+			err = yaml.UnmarshalStrict(content, out)
+		}
+	case ".json":
+		if err = json.Unmarshal(content, &raw); err == nil {
+			if err = checkEnvReferences("", raw, reflect.TypeOf(out), "json"); err != nil {
+				return err
+			}
+			decoder := json.NewDecoder(bytes.NewReader(content))
+			decoder.DisallowUnknownFields()
+			err = decoder.Decode(out)
+		}
+	default:
+		return errors.Errorf("unsupported configuration file format %s, expected .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse configuration file %s", path)
+	}
+
+	var missingVars []string
+	expandEnv(reflect.ValueOf(out), &missingVars)
+	if len(missingVars) > 0 {
+		return errors.Errorf("configuration file %s references undefined environment variables %s", path, strings.Join(missingVars, ", "))
+	}
+	return nil
+}
+
+// checkEnvReferences returns *FieldError if environment variable is referenced by the value of non-string field,
+// raw is the value as parsed without the schema and t is the type of the field it is decoded into. Environment
+// variables are substituted after the file is decoded, so they can't provide values of other types
+func checkEnvReferences(field string, raw interface{}, t reflect.Type, tagKey string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch v := raw.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range v {
+			if name, ok := key.(string); ok {
+				if err := checkStructFieldEnvReferences(field, name, value, t, tagKey); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		for name, value := range v {
+			if err := checkStructFieldEnvReferences(field, name, value, t, tagKey); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		for i, value := range v {
+			if err := checkEnvReferences(fmt.Sprintf("%s[%d]", field, i), value, t.Elem(), tagKey); err != nil {
+				return err
+			}
+		}
+	case string:
+		if t.Kind() != reflect.String && envReference.MatchString(v) {
+			return &FieldError{Field: field, Reason: "environment variables can be referenced only in string values"}
+		}
+	}
+	return nil
+}
+
+// checkStructFieldEnvReferences checks the value of the field of struct t, tagged with name, unknown fields are
+// reported later by the strict decoding
+func checkStructFieldEnvReferences(parent, name string, value interface{}, t reflect.Type, tagKey string) error {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get(tagKey) == name {
+			field := name
+			if parent != "" {
+				field = parent + "." + name
+			}
+			return checkEnvReferences(field, value, t.Field(i).Type, tagKey)
+		}
+	}
+	return nil
+}
+
+// expandEnv substitutes environment variable references in all string fields reachable from v, names of
+// undefined variables are appended to missingVars
+func expandEnv(v reflect.Value, missingVars *[]string) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			expandEnv(v.Elem(), missingVars)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			expandEnv(v.Field(i), missingVars)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			expandEnv(v.Index(i), missingVars)
+		}
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(envReference.ReplaceAllStringFunc(v.String(), func(ref string) string {
+				match := envReference.FindStringSubmatch(ref)
+				if value, ok := os.LookupEnv(match[1]); ok {
+					return value
+				}
+				if match[2] != "" {
+					return match[3]
+				}
+				*missingVars = append(*missingVars, match[1])
+				return ref
+			}))
+		}
+	}
+}
+
+func parseDuration(field, value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, &FieldError{Field: field, Reason: fmt.Sprintf("invalid duration %q, expected e.g. \"5s\"", value)}
+	}
+	if d < 0 {
+		return 0, &FieldError{Field: field, Reason: fmt.Sprintf("negative duration %q", value)}
+	}
+	return d, nil
+}
+
+// resolvePath resolves path relative to the directory of the configuration file
+func resolvePath(configPath, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(configPath), path)
+}
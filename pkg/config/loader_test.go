@@ -0,0 +1,310 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	configPath := path.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(configPath, []byte(content), 0644))
+	return configPath
+}
+
+func TestLoadConnectionConfig(t *testing.T) {
+	require.NoError(t, os.Setenv("BCDB_TEST_NODE2_ENDPOINT", "http://node2:6001"))
+	defer os.Unsetenv("BCDB_TEST_NODE2_ENDPOINT")
+
+	yamlPath := writeConfigFile(t, "connection.yaml", `
+replicaSet:
+  - id: node1
+    endpoint: http://node1:6001
+  - id: node2
+    endpoint: ${BCDB_TEST_NODE2_ENDPOINT}
+rootCAs:
+  - crypto/CA.pem
+  - /etc/bcdb/CA.pem
+replicaSelection:
+  strategy: round-robin
+  healthCheckInterval: ${BCDB_TEST_HEALTH_CHECK_INTERVAL:-3s}
+discovery:
+  refreshInterval: 30s
+`)
+	jsonPath := writeConfigFile(t, "connection.json", `{
+	"replicaSet": [
+		{"id": "node1", "endpoint": "http://node1:6001"},
+		{"id": "node2", "endpoint": "${BCDB_TEST_NODE2_ENDPOINT}"}
+	],
+	"rootCAs": ["crypto/CA.pem", "/etc/bcdb/CA.pem"],
+	"replicaSelection": {
+		"strategy": "round-robin",
+		"healthCheckInterval": "3s"
+	},
+	"discovery": {"refreshInterval": "30s"}
+}`)
+
+	for _, configPath := range []string{yamlPath, jsonPath} {
+		c, err := LoadConnectionConfig(configPath)
+		require.NoError(t, err)
+		require.Equal(t, &ConnectionConfig{
+			ReplicaSet: []*Replica{
+				{ID: "node1", Endpoint: "http://node1:6001"},
+				{ID: "node2", Endpoint: "http://node2:6001"},
+			},
+			RootCAs: []string{
+				path.Join(path.Dir(configPath), "crypto/CA.pem"),
+				"/etc/bcdb/CA.pem",
+			},
+			ReplicaSelection: &ReplicaSelectionConfig{
+				Strategy:            ReplicaSelectionRoundRobin,
+				HealthCheckInterval: 3 * time.Second,
+			},
+			Discovery: &DiscoveryConfig{
+				RefreshInterval: 30 * time.Second,
+			},
+		}, c)
+	}
+}
+
+func TestLoadConnectionConfig_TLS(t *testing.T) {
+	configPath := writeConfigFile(t, "connection.yaml", `
+replicaSet:
+  - id: node1
+    endpoint: https://node1:6001
+rootCAs:
+  - CA.pem
+tls:
+  caCertsPaths:
+    - tlsCA.pem
+  clientCertPath: client.pem
+  clientKeyPath: client.key
+  serverNameOverride: node1.bcdb.example
+  minVersion: "1.3"
+`)
+	dir := path.Dir(configPath)
+
+	c, err := LoadConnectionConfig(configPath)
+	require.NoError(t, err)
+	require.Equal(t, &TLSConfig{
+		CACertsPaths:       []string{path.Join(dir, "tlsCA.pem")},
+		ClientCertPath:     path.Join(dir, "client.pem"),
+		ClientKeyPath:      path.Join(dir, "client.key"),
+		ServerNameOverride: "node1.bcdb.example",
+		MinVersion:         tls.VersionTLS13,
+	}, c.TLS)
+
+	_, err = LoadConnectionConfig(writeConfigFile(t, "connection.yaml", `
+replicaSet:
+  - id: node1
+    endpoint: https://node1:6001
+tls:
+  clientCertPath: client.pem
+`))
+	require.EqualError(t, err, "invalid configuration field tls.clientKeyPath: client certificate and key should be set together")
+
+	_, err = LoadConnectionConfig(writeConfigFile(t, "connection.yaml", `
+replicaSet:
+  - id: node1
+    endpoint: https://node1:6001
+tls:
+  minVersion: "1.4"
+`))
+	fieldErr := &FieldError{}
+	require.True(t, errors.As(err, &fieldErr))
+	require.Equal(t, "tls.minVersion", fieldErr.Field)
+}
+
+func TestLoadConnectionConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		field   string
+		errMsg  string
+	}{
+		{
+			name:    "no replicas",
+			content: "rootCAs: [CA.pem]\n",
+			field:   "replicaSet",
+		},
+		{
+			name:    "bad endpoint",
+			content: "replicaSet:\n  - id: node1\n    endpoint: http://node1:6001\n  - id: node2\n    endpoint: node2\n",
+			field:   "replicaSet[1].endpoint",
+		},
+		{
+			name:    "duplicate replica",
+			content: "replicaSet:\n  - id: node1\n    endpoint: http://node1:6001\n  - id: node1\n    endpoint: http://node2:6001\n",
+			field:   "replicaSet[1].id",
+		},
+		{
+			name:    "bad strategy",
+			content: "replicaSet:\n  - id: node1\n    endpoint: http://node1:6001\nreplicaSelection:\n  strategy: random\n",
+			field:   "replicaSelection.strategy",
+		},
+		{
+			name:    "bad duration",
+			content: "replicaSet:\n  - id: node1\n    endpoint: http://node1:6001\nreplicaSelection:\n  healthCheckTimeout: 5\n",
+			field:   "replicaSelection.healthCheckTimeout",
+		},
+		{
+			name:    "unknown field",
+			content: "replicaSet:\n  - id: node1\n    endpoint: http://node1:6001\n    url: http://node1:6001\n",
+			errMsg:  "field url not found",
+		},
+		{
+			name:    "undefined environment variable",
+			content: "replicaSet:\n  - id: node1\n    endpoint: ${BCDB_TEST_UNDEFINED}\n",
+			errMsg:  "references undefined environment variables BCDB_TEST_UNDEFINED",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := LoadConnectionConfig(writeConfigFile(t, "connection.yml", tt.content))
+			require.Nil(t, c)
+			require.Error(t, err)
+			if tt.field != "" {
+				fieldErr := &FieldError{}
+				require.True(t, errors.As(err, &fieldErr))
+				require.Equal(t, tt.field, fieldErr.Field)
+			} else {
+				require.Contains(t, err.Error(), tt.errMsg)
+			}
+		})
+	}
+
+	_, err := LoadConnectionConfig(writeConfigFile(t, "connection.toml", ""))
+	require.Contains(t, err.Error(), "unsupported configuration file format")
+}
+
+func TestLoadSessionConfig(t *testing.T) {
+	configPath := writeConfigFile(t, "session.yaml", `
+userConfig:
+  userID: alice
+  certPath: crypto/alice.pem
+  privateKeyPath: crypto/alice.key
+txTimeout: 20s
+receiptPolling:
+  maxInterval: 1s
+strictSnapshotReads: true
+ledgerVerification:
+  checkpointPath: checkpoint.json
+nodeCertsCacheTTL: 1m
+`)
+	dir := path.Dir(configPath)
+
+	c, err := LoadSessionConfig(configPath)
+	require.NoError(t, err)
+	require.Equal(t, &SessionConfig{
+		UserConfig: &UserConfig{
+			UserID:         "alice",
+			CertPath:       path.Join(dir, "crypto/alice.pem"),
+			PrivateKeyPath: path.Join(dir, "crypto/alice.key"),
+		},
+		TxTimeout:    20 * time.Second,
+		QueryTimeout: DefaultQueryTimeout,
+		ReceiptPolling: &ReceiptPollingConfig{
+			MaxInterval: time.Second,
+		},
+		StrictSnapshotReads: true,
+		LedgerVerification: &LedgerVerificationConfig{
+			CheckpointPath: path.Join(dir, "checkpoint.json"),
+		},
+		NodeCertsCacheTTL: time.Minute,
+	}, c)
+
+	c, err = LoadSessionConfig(writeConfigFile(t, "session.json", `{"userConfig": {"userID": "alice", "certPath": "/crypto/alice.pem", "privateKeyPath": "/crypto/alice.key"}}`))
+	require.NoError(t, err)
+	require.Equal(t, DefaultTxTimeout, c.TxTimeout)
+	require.Equal(t, DefaultQueryTimeout, c.QueryTimeout)
+	require.Nil(t, c.ReceiptPolling)
+	require.Nil(t, c.LedgerVerification)
+
+	_, err = LoadSessionConfig(writeConfigFile(t, "session.json", `{"userConfig": {"userID": "alice", "certPath": "/crypto/alice.pem"}}`))
+	fieldErr := &FieldError{}
+	require.True(t, errors.As(err, &fieldErr))
+	require.EqualError(t, err, "invalid configuration field userConfig.privateKeyPath: path is empty")
+
+	_, err = LoadSessionConfig(writeConfigFile(t, "session.yaml", "userConfig:\n  userID: alice\n  certPath: a.pem\n  privateKeyPath: a.key\nqueryTimeout: -1s\n"))
+	require.EqualError(t, err, `invalid configuration field queryTimeout: negative duration "-1s"`)
+}
+
+func TestLoadSessionConfig_Passphrase(t *testing.T) {
+	configPath := writeConfigFile(t, "session.yaml", `
+userConfig:
+  userID: alice
+  certPath: alice.pem
+  privateKeyPath: alice.key
+  passphraseFile: passphrase
+`)
+	require.NoError(t, ioutil.WriteFile(path.Join(path.Dir(configPath), "passphrase"), []byte("secret\n"), 0600))
+
+	c, err := LoadSessionConfig(configPath)
+	require.NoError(t, err)
+	passphrase, err := c.UserConfig.PassphraseProvider()
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), passphrase)
+
+	require.NoError(t, os.Setenv("BCDB_TEST_PASSPHRASE", "secret"))
+	defer os.Unsetenv("BCDB_TEST_PASSPHRASE")
+	c, err = LoadSessionConfig(writeConfigFile(t, "session.json", `{"userConfig": {"userID": "alice", "certPath": "/alice.pem", "privateKeyPath": "/alice.key", "passphraseEnv": "BCDB_TEST_PASSPHRASE"}}`))
+	require.NoError(t, err)
+	passphrase, err = c.UserConfig.PassphraseProvider()
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), passphrase)
+
+	_, err = LoadSessionConfig(writeConfigFile(t, "session.yaml", "userConfig:\n  userID: alice\n  certPath: a.pem\n  privateKeyPath: a.key\n  passphraseEnv: PASS\n  passphraseFile: pass.txt\n"))
+	require.EqualError(t, err, "invalid configuration field userConfig.passphraseFile: passphrase environment variable and file are mutually exclusive")
+}
+
+func TestLoadSessionConfig_EnvExpansion(t *testing.T) {
+	require.NoError(t, os.Setenv("BCDB_TEST_USER_ID", "alice \"the admin\"\nsecond line"))
+	defer os.Unsetenv("BCDB_TEST_USER_ID")
+
+	yamlPath := writeConfigFile(t, "session.yaml", `
+# userID is set by ${BCDB_TEST_UNDEFINED}, ignored in comments
+userConfig:
+  userID: ${BCDB_TEST_USER_ID}
+  certPath: /crypto/${BCDB_TEST_CERT_NAME:-alice}.pem
+  privateKeyPath: "/crypto/${BCDB_TEST_KEY_NAME:-}alice.key"
+`)
+	jsonPath := writeConfigFile(t, "session.json", `{"userConfig": {"userID": "${BCDB_TEST_USER_ID}", "certPath": "/crypto/${BCDB_TEST_CERT_NAME:-alice}.pem", "privateKeyPath": "/crypto/${BCDB_TEST_KEY_NAME:-}alice.key"}}`)
+
+	for _, configPath := range []string{yamlPath, jsonPath} {
+		c, err := LoadSessionConfig(configPath)
+		require.NoError(t, err)
+		require.Equal(t, &UserConfig{
+			UserID:         "alice \"the admin\"\nsecond line",
+			CertPath:       "/crypto/alice.pem",
+			PrivateKeyPath: "/crypto/alice.key",
+		}, c.UserConfig)
+	}
+
+	_, err := LoadSessionConfig(writeConfigFile(t, "session.yaml", "userConfig:\n  userID: ${BCDB_TEST_UNDEFINED}\n  certPath: ${BCDB_TEST_UNDEFINED_CERT}\n  privateKeyPath: a.key\n"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "references undefined environment variables BCDB_TEST_UNDEFINED, BCDB_TEST_UNDEFINED_CERT")
+
+	// Environment variables are substituted in string values only
+	require.NoError(t, os.Setenv("BCDB_TEST_STRICT_READS", "true"))
+	defer os.Unsetenv("BCDB_TEST_STRICT_READS")
+	for _, configPath := range []string{
+		writeConfigFile(t, "session.yaml", "userConfig:\n  userID: alice\n  certPath: a.pem\n  privateKeyPath: a.key\nstrictSnapshotReads: ${BCDB_TEST_STRICT_READS}\n"),
+		writeConfigFile(t, "session.json", `{"userConfig": {"userID": "alice", "certPath": "a.pem", "privateKeyPath": "a.key"}, "strictSnapshotReads": "${BCDB_TEST_STRICT_READS}"}`),
+	} {
+		_, err = LoadSessionConfig(configPath)
+		fieldErr := &FieldError{}
+		require.True(t, errors.As(err, &fieldErr))
+		require.EqualError(t, err, "invalid configuration field strictSnapshotReads: environment variables can be referenced only in string values")
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// PassphraseProvider returns the passphrase to decrypt user's private key, called
+// only if the private key is encrypted
+type PassphraseProvider func() ([]byte, error)
+
+// EnvPassphraseProvider reads the passphrase from the environment variable
+func EnvPassphraseProvider(name string) PassphraseProvider {
+	return func() ([]byte, error) {
+		passphrase, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, errors.Errorf("passphrase environment variable %s is not set", name)
+		}
+		return []byte(passphrase), nil
+	}
+}
+
+// FilePassphraseProvider reads the passphrase from the file, trailing line break is ignored
+func FilePassphraseProvider(path string) PassphraseProvider {
+	return func() ([]byte, error) {
+		passphrase, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read passphrase file %s", path)
+		}
+		return bytes.TrimRight(passphrase, "\r\n"), nil
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassphraseProviders(t *testing.T) {
+	require.NoError(t, os.Setenv("BCDB_TEST_PASSPHRASE", "secret\n"))
+	defer os.Unsetenv("BCDB_TEST_PASSPHRASE")
+
+	passphrase, err := EnvPassphraseProvider("BCDB_TEST_PASSPHRASE")()
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret\n"), passphrase)
+
+	_, err = EnvPassphraseProvider("BCDB_TEST_UNDEFINED")()
+	require.EqualError(t, err, "passphrase environment variable BCDB_TEST_UNDEFINED is not set")
+
+	passphrasePath := writeConfigFile(t, "passphrase", "secret\r\n")
+	passphrase, err = FilePassphraseProvider(passphrasePath)()
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), passphrase)
+
+	_, err = FilePassphraseProvider(passphrasePath + ".missing")()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to read passphrase file")
+}
@@ -0,0 +1,354 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wallet implements encrypted on-disk keystore of user identities. Wallet is a directory,
+// each identity is kept in its own file with the certificate, the private key, encrypted with the
+// wallet passphrase as PKCS#8, and the free form metadata
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/internal/pkcs8"
+	"github.com/IBM-Blockchain/bcdb-sdk/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	walletFileName     = "wallet.json"
+	identityFileSuffix = ".id"
+	walletVersion      = 1
+	// walletCheckMessage message authenticated by the passphrase check of the wallet file
+	walletCheckMessage = "bcdb wallet passphrase check"
+)
+
+var (
+	// ErrIdentityExists returned when imported identity already exists in the wallet
+	ErrIdentityExists = errors.New("identity already exists")
+	// ErrIdentityNotFound returned when identity is not in the wallet
+	ErrIdentityNotFound = errors.New("identity not found")
+	// ErrIncorrectPassphrase returned when wallet is opened with wrong passphrase
+	ErrIncorrectPassphrase = errors.New("incorrect wallet passphrase")
+)
+
+var userIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]*$`)
+
+// Identity user's credentials kept in the wallet
+type Identity struct {
+	// UserID the identity of the user
+	UserID string
+	// Cert PEM encoded user's certificate
+	Cert []byte
+	// PrivateKey PEM encoded, not encrypted, user's private key, nil in identities returned by List
+	PrivateKey []byte
+	// Metadata free form information attached to the identity, e.g. environment it is used in
+	Metadata map[string]string
+	// Created time identity was imported
+	Created time.Time
+	// Updated time identity credentials were rotated, same as Created if never rotated
+	Updated time.Time
+}
+
+// Wallet encrypted keystore of user identities, kept in the directory
+type Wallet struct {
+	dir        string
+	passphrase []byte
+}
+
+type walletFile struct {
+	Version int `json:"version"`
+	// Salt and Iterations of PBKDF2 derivation of the passphrase check key
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+	// Check HMAC-SHA256 of walletCheckMessage under the key derived from the wallet passphrase
+	Check []byte `json:"check"`
+}
+
+type identityFile struct {
+	UserID     string            `json:"userID"`
+	Cert       string            `json:"cert"`
+	PrivateKey string            `json:"privateKey"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Created    time.Time         `json:"created"`
+	Updated    time.Time         `json:"updated"`
+}
+
+// Open opens the wallet kept in the directory, new wallet is created if the directory doesn't
+// contain one. The passphrase protects private keys of all identities in the wallet
+func Open(dir string, passphraseProvider config.PassphraseProvider) (*Wallet, error) {
+	if passphraseProvider == nil {
+		return nil, errors.New("wallet passphrase provider is not set")
+	}
+	passphrase, err := passphraseProvider()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get wallet passphrase")
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("wallet passphrase is empty")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create wallet directory %s", dir)
+	}
+
+	w := &Wallet{
+		dir:        dir,
+		passphrase: passphrase,
+	}
+	walletPath := filepath.Join(dir, walletFileName)
+	content, err := ioutil.ReadFile(walletPath)
+	if os.IsNotExist(err) {
+		return w, w.create()
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read wallet file %s", walletPath)
+	}
+
+	file := &walletFile{}
+	if err := json.Unmarshal(content, file); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse wallet file %s", walletPath)
+	}
+	if file.Version != walletVersion {
+		return nil, errors.Errorf("unsupported wallet version %d", file.Version)
+	}
+	if len(file.Salt) == 0 || file.Iterations <= 0 || len(file.Check) == 0 {
+		return nil, errors.Errorf("wallet file %s has no passphrase check", walletPath)
+	}
+	if !hmac.Equal(file.Check, passphraseCheck(passphrase, file.Salt, file.Iterations)) {
+		return nil, ErrIncorrectPassphrase
+	}
+	return w, nil
+}
+
+func (w *Wallet) create() error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return errors.Wrap(err, "failed to generate wallet passphrase check salt")
+	}
+	content, err := json.Marshal(&walletFile{
+		Version:    walletVersion,
+		Salt:       salt,
+		Iterations: pkcs8.DefaultIterations,
+		Check:      passphraseCheck(w.passphrase, salt, pkcs8.DefaultIterations),
+	})
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(w.dir, walletFileName), content)
+}
+
+// Import adds the identity to the wallet, the private key should match the certificate
+func (w *Wallet) Import(identity *Identity) error {
+	if err := validateUserID(identity.UserID); err != nil {
+		return err
+	}
+	if _, err := os.Stat(w.identityPath(identity.UserID)); err == nil {
+		return errors.Wrapf(ErrIdentityExists, "failed to import identity %s", identity.UserID)
+	}
+
+	now := time.Now().UTC()
+	return w.store(identity.UserID, identity.Cert, identity.PrivateKey, identity.Metadata, now, now)
+}
+
+// Export returns the identity with decrypted private key
+func (w *Wallet) Export(userID string) (*Identity, error) {
+	file, err := w.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(file.PrivateKey))
+	if block == nil {
+		return nil, errors.Errorf("no PEM encoded private key found in identity %s", userID)
+	}
+	key, err := pkcs8.Decrypt(block.Bytes, w.passphrase)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt private key of identity %s", userID)
+	}
+	if _, err = x509.ParsePKCS8PrivateKey(key); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse decrypted private key of identity %s", userID)
+	}
+	identity := file.identity()
+	identity.PrivateKey = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	return identity, nil
+}
+
+// List returns all identities in the wallet, sorted by user ID, without private keys
+func (w *Wallet) List() ([]*Identity, error) {
+	entries, err := ioutil.ReadDir(w.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read wallet directory %s", w.dir)
+	}
+	var identities []*Identity
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), identityFileSuffix) {
+			continue
+		}
+		file, err := w.load(strings.TrimSuffix(entry.Name(), identityFileSuffix))
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, file.identity())
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].UserID < identities[j].UserID
+	})
+	return identities, nil
+}
+
+// Rotate replaces certificate and private key of existing identity, metadata is kept
+func (w *Wallet) Rotate(userID string, cert, privateKey []byte) error {
+	file, err := w.load(userID)
+	if err != nil {
+		return err
+	}
+	return w.store(userID, cert, privateKey, file.Metadata, file.Created, time.Now().UTC())
+}
+
+// Delete removes the identity from the wallet
+func (w *Wallet) Delete(userID string) error {
+	if err := validateUserID(userID); err != nil {
+		return err
+	}
+	err := os.Remove(w.identityPath(userID))
+	if os.IsNotExist(err) {
+		return errors.Wrapf(ErrIdentityNotFound, "failed to delete identity %s", userID)
+	}
+	return errors.Wrapf(err, "failed to delete identity %s", userID)
+}
+
+func (w *Wallet) store(userID string, cert, privateKey []byte, metadata map[string]string, created, updated time.Time) error {
+	keyDER, err := validateCredentials(cert, privateKey)
+	if err != nil {
+		return errors.WithMessagef(err, "invalid credentials of identity %s", userID)
+	}
+	encryptedKey, err := pkcs8.Encrypt(keyDER, w.passphrase)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encrypt private key of identity %s", userID)
+	}
+
+	content, err := json.MarshalIndent(&identityFile{
+		UserID:     userID,
+		Cert:       string(cert),
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encryptedKey})),
+		Metadata:   metadata,
+		Created:    created,
+		Updated:    updated,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(w.identityPath(userID), content)
+}
+
+func (w *Wallet) load(userID string) (*identityFile, error) {
+	if err := validateUserID(userID); err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(w.identityPath(userID))
+	if os.IsNotExist(err) {
+		return nil, errors.Wrapf(ErrIdentityNotFound, "failed to load identity %s", userID)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load identity %s", userID)
+	}
+	file := &identityFile{}
+	if err := json.Unmarshal(content, file); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse identity %s", userID)
+	}
+	if file.UserID != userID {
+		return nil, errors.Errorf("identity file of %s holds identity %s", userID, file.UserID)
+	}
+	return file, nil
+}
+
+// passphraseCheck returns HMAC-SHA256 of walletCheckMessage, keyed with the key derived from the passphrase
+func passphraseCheck(passphrase, salt []byte, iterations int) []byte {
+	mac := hmac.New(sha256.New, pkcs8.Key(passphrase, salt, iterations, 32, sha256.New))
+	mac.Write([]byte(walletCheckMessage))
+	return mac.Sum(nil)
+}
+
+func (w *Wallet) identityPath(userID string) string {
+	return filepath.Join(w.dir, userID+identityFileSuffix)
+}
+
+func (f *identityFile) identity() *Identity {
+	return &Identity{
+		UserID:   f.UserID,
+		Cert:     []byte(f.Cert),
+		Metadata: f.Metadata,
+		Created:  f.Created,
+		Updated:  f.Updated,
+	}
+}
+
+func validateUserID(userID string) error {
+	if !userIDPattern.MatchString(userID) {
+		return errors.Errorf("invalid user ID %q, letters, digits and '.', '_', '@', '-' are allowed", userID)
+	}
+	return nil
+}
+
+// validateCredentials checks the private key matches the certificate, returns PKCS#8 DER encoded private key
+func validateCredentials(cert, privateKey []byte) ([]byte, error) {
+	certBlock, _ := pem.Decode(cert)
+	if certBlock == nil || certBlock.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	x509Cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse certificate")
+	}
+
+	keyBlock, _ := pem.Decode(privateKey)
+	if keyBlock == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+	var key interface{}
+	if key, err = x509.ParsePKCS8PrivateKey(keyBlock.Bytes); err != nil {
+		if key, err = x509.ParseECPrivateKey(keyBlock.Bytes); err != nil {
+			return nil, errors.Wrap(err, "failed to parse private key, encrypted keys should be decrypted before import")
+		}
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("unsupported private key type %T, ECDSA key expected", key)
+	}
+	certKey, ok := x509Cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok || !certKey.Equal(&ecdsaKey.PublicKey) {
+		return nil, errors.New("private key doesn't match the certificate")
+	}
+	return x509.MarshalPKCS8PrivateKey(ecdsaKey)
+}
+
+// writeFile writes the file atomically, readable by the owner only
+func writeFile(filePath string, content []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filePath), filepath.Base(filePath)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", filePath)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(content); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filePath)
+	}
+	return errors.Wrapf(err, "failed to write %s", filePath)
+}
@@ -0,0 +1,215 @@
+// Copyright IBM Corp. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM-Blockchain/bcdb-sdk/internal/pkcs8"
+	"github.com/stretchr/testify/require"
+)
+
+func passphrase(p string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return []byte(p), nil
+	}
+}
+
+// generateCredentials returns PEM encoded self-signed certificate and PKCS#8 private key
+func generateCredentials(t *testing.T, userID string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: userID},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})
+}
+
+func TestWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	walletDir := path.Join(dir, "wallet")
+
+	w, err := Open(walletDir, passphrase("secret"))
+	require.NoError(t, err)
+
+	aliceCert, aliceKey := generateCredentials(t, "alice")
+	bobCert, bobKey := generateCredentials(t, "bob")
+	require.NoError(t, w.Import(&Identity{
+		UserID:     "bob",
+		Cert:       bobCert,
+		PrivateKey: bobKey,
+	}))
+	require.NoError(t, w.Import(&Identity{
+		UserID:     "alice",
+		Cert:       aliceCert,
+		PrivateKey: aliceKey,
+		Metadata:   map[string]string{"env": "staging"},
+	}))
+
+	err = w.Import(&Identity{UserID: "alice", Cert: aliceCert, PrivateKey: aliceKey})
+	require.True(t, errors.Is(err, ErrIdentityExists))
+
+	// private keys are not stored in plain text
+	files, err := ioutil.ReadDir(walletDir)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	for _, f := range files {
+		content, err := ioutil.ReadFile(path.Join(walletDir, f.Name()))
+		require.NoError(t, err)
+		require.NotContains(t, string(content), strings.TrimSpace(string(aliceKey)))
+		require.Equal(t, os.FileMode(0600), f.Mode().Perm())
+	}
+
+	identities, err := w.List()
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+	require.Equal(t, "alice", identities[0].UserID)
+	require.Equal(t, aliceCert, identities[0].Cert)
+	require.Nil(t, identities[0].PrivateKey)
+	require.Equal(t, map[string]string{"env": "staging"}, identities[0].Metadata)
+	require.Equal(t, "bob", identities[1].UserID)
+
+	// reopen the wallet
+	w, err = Open(walletDir, passphrase("secret"))
+	require.NoError(t, err)
+	alice, err := w.Export("alice")
+	require.NoError(t, err)
+	require.Equal(t, aliceCert, alice.Cert)
+	require.Equal(t, aliceKey, alice.PrivateKey)
+	require.Equal(t, alice.Created, alice.Updated)
+
+	_, err = Open(walletDir, passphrase("wrong"))
+	require.Equal(t, ErrIncorrectPassphrase, err)
+
+	t.Run("rotate", func(t *testing.T) {
+		newCert, newKey := generateCredentials(t, "alice")
+		require.NoError(t, w.Rotate("alice", newCert, newKey))
+
+		rotated, err := w.Export("alice")
+		require.NoError(t, err)
+		require.Equal(t, newCert, rotated.Cert)
+		require.Equal(t, newKey, rotated.PrivateKey)
+		require.Equal(t, alice.Metadata, rotated.Metadata)
+		require.Equal(t, alice.Created, rotated.Created)
+		require.True(t, rotated.Updated.After(rotated.Created))
+
+		err = w.Rotate("alice", newCert, bobKey)
+		require.EqualError(t, err, "invalid credentials of identity alice: private key doesn't match the certificate")
+		err = w.Rotate("carol", newCert, newKey)
+		require.True(t, errors.Is(err, ErrIdentityNotFound))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, w.Delete("bob"))
+		_, err := w.Export("bob")
+		require.True(t, errors.Is(err, ErrIdentityNotFound))
+		require.True(t, errors.Is(w.Delete("bob"), ErrIdentityNotFound))
+
+		identities, err := w.List()
+		require.NoError(t, err)
+		require.Len(t, identities, 1)
+	})
+
+	t.Run("invalid identity", func(t *testing.T) {
+		err := w.Import(&Identity{UserID: "../carol", Cert: aliceCert, PrivateKey: aliceKey})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid user ID")
+
+		err = w.Import(&Identity{UserID: "carol", Cert: aliceKey, PrivateKey: aliceKey})
+		require.EqualError(t, err, "invalid credentials of identity carol: no PEM encoded certificate found")
+
+		err = w.Import(&Identity{UserID: "carol", Cert: aliceCert, PrivateKey: aliceCert})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to parse private key")
+	})
+}
+
+func TestOpen_Errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = Open(dir, nil)
+	require.EqualError(t, err, "wallet passphrase provider is not set")
+
+	_, err = Open(dir, passphrase(""))
+	require.EqualError(t, err, "wallet passphrase is empty")
+
+	_, err = Open(dir, func() ([]byte, error) {
+		return nil, errors.New("vault is sealed")
+	})
+	require.EqualError(t, err, "failed to get wallet passphrase: vault is sealed")
+
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, walletFileName), []byte(`{"version": 2}`), 0600))
+	_, err = Open(dir, passphrase("secret"))
+	require.EqualError(t, err, "unsupported wallet version 2")
+
+	walletPath := path.Join(dir, walletFileName)
+	require.NoError(t, ioutil.WriteFile(walletPath, []byte(`{"version": 1}`), 0600))
+	_, err = Open(dir, passphrase("secret"))
+	require.EqualError(t, err, "wallet file "+walletPath+" has no passphrase check")
+
+	// tampered passphrase check
+	require.NoError(t, os.Remove(walletPath))
+	_, err = Open(dir, passphrase("secret"))
+	require.NoError(t, err)
+	content, err := ioutil.ReadFile(walletPath)
+	require.NoError(t, err)
+	file := &walletFile{}
+	require.NoError(t, json.Unmarshal(content, file))
+	file.Check[0] ^= 1
+	content, err = json.Marshal(file)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(walletPath, content, 0600))
+	_, err = Open(dir, passphrase("secret"))
+	require.Equal(t, ErrIncorrectPassphrase, err)
+}
+
+func TestExport_NotPrivateKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w, err := Open(dir, passphrase("secret"))
+	require.NoError(t, err)
+	cert, key := generateCredentials(t, "alice")
+	require.NoError(t, w.Import(&Identity{UserID: "alice", Cert: cert, PrivateKey: key}))
+
+	// private key replaced with other data encrypted with the wallet passphrase
+	file, err := w.load("alice")
+	require.NoError(t, err)
+	encrypted, err := pkcs8.Encrypt([]byte("not a private key"), []byte("secret"))
+	require.NoError(t, err)
+	file.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encrypted}))
+	content, err := json.Marshal(file)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(w.identityPath("alice"), content, 0600))
+
+	_, err = w.Export("alice")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to parse decrypted private key of identity alice")
+}